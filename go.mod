@@ -3,6 +3,9 @@ module github.com/mantyr/git-semver/v6
 go 1.15
 
 require (
+	github.com/go-git/go-billy/v5 v5.0.0
 	github.com/go-git/go-git/v5 v5.2.0
 	github.com/stretchr/testify v1.4.0
+	golang.org/x/crypto v0.0.0-20200302210943-78000ba7a073
+	gopkg.in/yaml.v3 v3.0.1
 )
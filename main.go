@@ -1,26 +1,127 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"regexp"
+	"strconv"
+	"strings"
 
+	"github.com/go-git/go-git/v5"
 	"github.com/mantyr/git-semver/v6/version"
+	"golang.org/x/crypto/openpgp"
 )
 
 var prefix = flag.String("prefix", "", "prefix of version string e.g. v (default: none)")
 var format = flag.String("format", "", "format string (e.g.: x.y.z-p+m)")
 var excludeHash = flag.Bool("no-hash", false, "exclude commit hash (default: false)")
 var excludeMeta = flag.Bool("no-meta", false, "exclude build metadata (default: false)")
-var setMeta = flag.String("set-meta", "", "set build metadata (default: none)")
+var setMeta = flag.String("set-meta", "", "set build metadata, ${VAR}, {env:VAR}, {commits}, {hash}, {branch} and {tagtime} are replaced (default: none)")
 var excludePreRelease = flag.Bool("no-pre", false, "exclude pre-release version (default: false)")
 var excludePatch = flag.Bool("no-patch", false, "exclude pre-release version (default: false)")
 var excludeMinor = flag.Bool("no-minor", false, "exclude pre-release version (default: false)")
 var releaseCandidate = flag.Bool("release-candidate", false, "add release candidate (default: false)")
+var describe = flag.Bool("describe", false, "print the version like git describe would (default: false)")
+var stripPrefix = flag.Bool("strip-prefix", false, "remove the prefix detected in the tag (default: false)")
+var noIncrement = flag.Bool("no-increment", false, "don't increment the patch version for commits after a tag (default: false)")
+var strictTags = flag.Bool("strict-tags", false, "fail if multiple tags with different versions point to the same commit (default: false)")
+var validateTags = flag.Bool("validate-tags", false, "list all tags that are not a valid version and fail if there are any (default: false)")
+var dotenv = flag.String("dotenv", "", "write the version components as dotenv to this file (default: none)")
+var count = flag.Bool("count", false, "print only the number of commits since the last tag (default: false)")
+var validate = flag.Bool("validate", false, "fail if the resulting version is not a valid SemVer 2.0 version (default: false)")
+var plan = flag.Bool("plan", false, "print the current and the next version based on conventional commits (default: false)")
+var jsonOutput = flag.Bool("json", false, "print the output as JSON (default: false)")
+var strict = flag.Bool("strict", false, "stop at the first repo that fails if multiple repos are given (default: false)")
+var dateSource = flag.String("date-source", "committer", "date of the head commit that is used for the d format token: author or committer")
+var excludePreTags = flag.Bool("exclude-pre-tags", false, "ignore tags with a pre-release and derive the version from the last stable tag (default: false)")
+var matchRegex = flag.String("match-regex", "", "only consider tags matching this regular expression (default: none)")
+var noNewline = flag.Bool("no-newline", false, "don't print a trailing newline (default: false)")
+var allowShortTags = flag.Bool("allow-short-tags", false, "accept tags without patch version like v1.2 (default: false)")
+var export = flag.Bool("export", false, "write the describe information to "+version.ArchivalFile+" in the repo, so that the version can be derived without git history (default: false)")
+var metaSep = flag.String("meta-sep", "+", "separator in front of the build metadata")
+var requireTags = flag.Bool("require-tags", false, "fail if no tag is found instead of using 0.0.0 (default: false)")
+var tmpl = flag.String("template", "", "Go text/template to render the version with, e.g. {{.Major}}.{{.Minor}} (default: none)")
+var allTags = flag.Bool("all-tags", false, "also consider tags fetched from remotes into refs/remotes/<remote>/tags/ (default: false)")
+var fromStdin = flag.Bool("stdin", false, "read versions from stdin line by line and print them normalized (default: false)")
+var appendHash = flag.Bool("append-hash", false, "append the commit hash to the metadata of the tag for commits after the tag (default: false)")
+var setMajor = flag.Int("set-major", -1, "override the major version (default: computed)")
+var setMinor = flag.Int("set-minor", -1, "override the minor version (default: computed)")
+var setPatch = flag.Int("set-patch", -1, "override the patch version, it isn't incremented for commits after the tag then (default: computed)")
+var useCache = flag.Bool("cache", false, "read and write the describe cache in the git directory (default: false)")
+var lastTag = flag.Bool("last-tag", false, "print the name of the last tag verbatim, an empty line if there is none (default: false)")
+var skipNonSemver = flag.Bool("skip-non-semver", false, "ignore tags that are no valid version (default: false)")
+var createTag = flag.Bool("tag", false, "create an annotated tag for the next version at HEAD and print its name (default: false)")
+var bump = flag.String("bump", "", "version component incremented by -tag: major, minor or patch (default: based on conventional commits)")
+var dryRun = flag.Bool("dry-run", false, "print the tag that -tag would create without creating it (default: false)")
+var tagMessage = flag.String("message", "", "annotation of the tag created by -tag (default: Release <tag>)")
+var force = flag.Bool("force", false, "create the tag with -tag even if the worktree is dirty (default: false)")
+var sign = flag.Bool("sign", false, "sign the tag created by -tag with the key given by -signing-key (default: false)")
+var signingKey = flag.String("signing-key", "", "file with the armored, unencrypted OpenPGP private key for -sign (default: none)")
+var commitOffset = flag.Int("commit-offset", 0, "number added to the commits since the last tag, e.g. to continue former build numbers (default: 0)")
+var formats = flag.String("formats", "", "comma-separated list of formats or names like full, no-meta, core, no-patch, major and rc to print at once (default: none)")
+var fromTag = flag.String("from-tag", "", "derive the version from this tag instead of a repository (default: none)")
+var commits = flag.Int("commits", 0, "number of commits since the tag given with -from-tag (default: 0)")
+var hash = flag.String("hash", "", "commit hash used as metadata with -from-tag (default: none)")
+var latest = flag.Bool("latest", false, "print the version of the highest tag in the repo regardless of HEAD (default: false)")
+var gitHashPrefix = flag.Bool("git-hash-prefix", false, "prepend g to the commit hash in the metadata like git describe (default: false)")
+var submodule = flag.String("submodule", "", "print the version of the submodule with this name (default: none)")
+var metaCommits = flag.Bool("meta-commits", false, "use the number of commits since the last tag instead of the hash as metadata (default: false)")
+var abbrev = flag.Int("abbrev", 0, "number of characters of the commit hash in the metadata, 4 to 40 like git (default: 8)")
+var short = flag.Bool("short", false, "abbreviate the commit hash to 7 characters like git, unless -abbrev is given (default: false)")
+var totalCommits = flag.Bool("total-commits", false, "print only the number of all commits reachable from HEAD (default: false)")
+var fromJSON = flag.String("from-json", "", "derive the version from the JSON written by -json or -export in this file, - for stdin (default: none)")
+var component = flag.String("component", "", "only consider the tags of this monorepo component, e.g. api for api/v1.2.3 (default: none)")
+var assertNewer = flag.Bool("assert-newer", false, "fail if the version is not newer than the highest existing tag (default: false)")
+var ignoreUntracked = flag.Bool("ignore-untracked", false, "don't treat untracked files as dirty for -tag and -require-clean (default: false)")
+var strictSemver = flag.Bool("strict-semver", false, "treat tags and versions with a prefix like v as invalid (default: false)")
+var sinceTag = flag.String("since-tag", "", "derive the version from this tag instead of the nearest one, e.g. for back-ports (default: none)")
+var colorMode = flag.String("color", "auto", "highlight the version components with ANSI colors: auto, always or never")
+var requireClean = flag.Bool("require-clean", false, "fail and print the dirty files to stderr if the worktree is dirty (default: false)")
+var devSeparator = flag.String("dev-separator", ".", "separator between the pre-release of the tag and dev.<n>, a dot or alphanumerics and hyphens")
+var compare = flag.Bool("compare", false, "compare the two versions given as arguments, print -1, 0 or 1 and exit with 0 if equal, 2 if lower or 3 if higher (default: false)")
+var buildNumber = flag.Int("build-number", 0, "use this number instead of the commits since the last tag for dev.<n> and the metadata, e.g. from CI (default: none)")
+var forcePre = flag.Bool("force-pre", false, "apply -build-number to tagged commits as well (default: false)")
+var normalizeCase = flag.Bool("normalize", false, "lowercase the prefix, pre-release and metadata, which can change the precedence (default: false)")
+var useGitBinary = flag.Bool("use-git-binary", false, "derive the describe information with the git binary in PATH instead of go-git (default: false)")
+var ignoreTags stringList
+var branchPreRelease = flag.Bool("branch-prerelease", false, "add the branch name to the pre-release if not on main or master (default: false)")
+var mainBranch = flag.String("main-branch", "", "branch that releases are made from for -branch-prerelease (default: main and master)")
+
+// stdin and stderr are replaced in tests.
+var (
+	stdin  io.Reader = os.Stdin
+	stderr io.Writer = os.Stderr
+)
+
+// stringList is a flag that can be given multiple times. An empty value clears
+// the list, which also resets it to its default.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	if value == "" {
+		*l = nil
+		return nil
+	}
+	*l = append(*l, value)
+	return nil
+}
 
 func init() {
+	flag.Var(&ignoreTags, "ignore-tag", "ignore the tag with this name, can be given multiple times (default: none)")
+	flag.BoolVar(noNewline, "n", false, "shorthand for -no-newline")
 	flag.Usage = func() {
-		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [opts] [<repo>]\n\nOptions:\n", os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [opts] [<repo>...]\n\n", os.Args[0])
+		fmt.Fprint(flag.CommandLine.Output(), "If <repo> is omitted, GIT_SEMVER_REPO, GIT_DIR, GIT_WORK_TREE or the working directory is used.\n\nOptions:\n")
 		flag.PrintDefaults()
 	}
 }
@@ -47,32 +148,830 @@ func selectFormat() string {
 	return format
 }
 
-func main() {
-	flag.Parse()
-	repoPath := flag.Arg(0)
-	if repoPath == "" {
-		var err error
-		repoPath, err = os.Getwd()
+// overrideCore sets the components of the core version given with -set-major,
+// -set-minor and -set-patch.
+func overrideCore(v version.Version) (version.Version, error) {
+	for _, o := range []struct {
+		name  string
+		value int
+	}{{"set-major", *setMajor}, {"set-minor", *setMinor}, {"set-patch", *setPatch}} {
+		if o.value < -1 {
+			return v, fmt.Errorf("invalid -%s: must not be negative", o.name)
+		}
+	}
+	if *setMajor >= 0 {
+		v.Major = *setMajor
+	}
+	if *setMinor >= 0 {
+		v.Minor = *setMinor
+	}
+	if *setPatch >= 0 {
+		v = v.WithPatch(*setPatch)
+	}
+	return v, nil
+}
+
+// formatVersion formats v according to the format and the metadata separator
+// given on the command line.
+func formatVersion(v version.Version) (string, error) {
+	return formatVersionAs(v, selectFormat())
+}
+
+// formatVersionAs formats v according to format and the metadata separator given
+// on the command line.
+func formatVersionAs(v version.Version, format string) (string, error) {
+	f, err := newFormatter(format)
+	if err != nil {
+		return "", err
+	}
+	return f.Format(v)
+}
+
+// newFormatter creates a formatter for format that uses the metadata separator
+// given on the command line.
+func newFormatter(format string) (*version.Formatter, error) {
+	f, err := version.NewFormatter(format)
+	if err != nil {
+		return nil, err
+	}
+	if *metaSep != "+" {
+		f = f.WithMetaSeparator(*metaSep)
+	}
+	return f, nil
+}
+
+// warnMetaSep warns on stderr that the output is no valid SemVer if another
+// metadata separator than + is given on the command line.
+func warnMetaSep() {
+	if *metaSep != "+" {
+		fmt.Fprintln(stderr, "warning: the output is no valid SemVer with -meta-sep")
+	}
+}
+
+// componentColors are the ANSI colors of the version components with -color.
+var componentColors = map[byte]string{
+	'x': "31",
+	'y': "33",
+	'z': "32",
+	'p': "36",
+	'P': "36",
+	'r': "36",
+	'n': "36",
+	'm': "90",
+	'H': "90",
+	'd': "90",
+}
+
+// colorize wraps the component s in the ANSI color of verb.
+func colorize(verb byte, s string) string {
+	return "\x1b[" + componentColors[verb] + "m" + s + "\x1b[0m"
+}
+
+// useColor reports whether the output to w is colored according to -color. In
+// auto mode colors are used if w is a terminal and NO_COLOR is not set.
+func useColor(w io.Writer) (bool, error) {
+	switch *colorMode {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto":
+		if os.Getenv("NO_COLOR") != "" {
+			return false, nil
+		}
+		f, ok := w.(*os.File)
+		return ok && isTerminal(f), nil
+	}
+	return false, fmt.Errorf("invalid color mode: %s", *colorMode)
+}
+
+// isTerminal reports whether f is a character device like a terminal rather
+// than a pipe or a regular file.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func selectOptions() ([]version.Option, error) {
+	var opts []version.Option
+	switch *dateSource {
+	case "author":
+		opts = append(opts, version.WithAuthorDate())
+	case "committer":
+	default:
+		return nil, fmt.Errorf("invalid date source: %s", *dateSource)
+	}
+	if *stripPrefix {
+		opts = append(opts, version.WithoutPrefix())
+	}
+	if *branchPreRelease {
+		opts = append(opts, version.WithBranchPreRelease())
+	}
+	if *mainBranch != "" {
+		opts = append(opts, version.WithMainBranch(*mainBranch))
+	}
+	if *strictTags {
+		opts = append(opts, version.WithStrictTags())
+	}
+	if *noIncrement {
+		opts = append(opts, version.WithoutAutoIncrement())
+	}
+	if *excludePreTags {
+		opts = append(opts, version.WithStableTagsOnly())
+	}
+	if *requireTags {
+		opts = append(opts, version.WithRequiredTags())
+	}
+	if *appendHash {
+		opts = append(opts, version.WithAppendHash())
+	}
+	if *allTags {
+		opts = append(opts, version.WithAllTags())
+	}
+	if *component != "" {
+		opts = append(opts, version.WithComponent(*component))
+	}
+	if *devSeparator != version.DefaultDevSeparator {
+		opts = append(opts, version.WithDevSeparator(*devSeparator))
+	}
+	if *strictSemver {
+		opts = append(opts, version.WithStrictSemver())
+	}
+	if *ignoreUntracked {
+		opts = append(opts, version.WithIgnoreUntracked())
+	}
+	if len(ignoreTags) > 0 {
+		opts = append(opts, version.WithIgnoreTags(ignoreTags...))
+	}
+	if *metaCommits {
+		opts = append(opts, version.WithMetaCommits())
+	}
+	switch {
+	case *abbrev != 0:
+		opts = append(opts, version.WithAbbrev(*abbrev))
+	case *short:
+		opts = append(opts, version.WithAbbrev(7))
+	}
+	if *gitHashPrefix {
+		opts = append(opts, version.WithGitHashPrefix())
+	}
+	if *commitOffset != 0 {
+		opts = append(opts, version.WithCommitOffset(*commitOffset))
+	}
+	if *buildNumber > 0 {
+		opts = append(opts, version.WithBuildNumber(*buildNumber))
+	}
+	if *forcePre {
+		opts = append(opts, version.WithForcePreRelease())
+	}
+	if *skipNonSemver {
+		opts = append(opts, version.WithSkipNonSemverTags())
+	}
+	if *useCache {
+		if *useGitBinary {
+			return nil, errors.New("-cache is not supported with -use-git-binary")
+		}
+		opts = append(opts, version.WithCache())
+	}
+	if *allowShortTags {
+		opts = append(opts, version.WithShortTags())
+	}
+	if *matchRegex != "" {
+		re, err := regexp.Compile(*matchRegex)
 		if err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(1)
+			return nil, fmt.Errorf("invalid -match-regex: %w", err)
 		}
+		opts = append(opts, version.WithMatchRegex(re))
 	}
-	v, err := version.NewFromRepo(repoPath)
+	return opts, nil
+}
+
+// repoPath returns the path of the repository, which is taken from the command
+// line arguments, the environment variables GIT_SEMVER_REPO, GIT_DIR or
+// GIT_WORK_TREE or the current working directory in this order. If GIT_DIR is
+// used and GIT_WORK_TREE is set as well, the path is the git directory and
+// workTree the path of the worktree.
+func repoPath(args []string) (path, workTree string, err error) {
+	if len(args) > 0 && args[0] != "" {
+		return args[0], "", nil
+	}
+	if path := os.Getenv("GIT_SEMVER_REPO"); path != "" {
+		return path, "", nil
+	}
+	if path := os.Getenv("GIT_DIR"); path != "" {
+		return path, os.Getenv("GIT_WORK_TREE"), nil
+	}
+	if path := os.Getenv("GIT_WORK_TREE"); path != "" {
+		return path, "", nil
+	}
+	path, err = os.Getwd()
+	return path, "", err
+}
+
+func run(args []string, w io.Writer) error {
+	if *fromStdin {
+		return runStdin(w)
+	}
+	if *compare {
+		return runCompare(args, w)
+	}
+	if *fromTag != "" {
+		return runHead(w, &version.RepoHead{
+			LastTag:         *fromTag,
+			CommitsSinceTag: *commits,
+			Hash:            *hash,
+		})
+	}
+	if *fromJSON != "" {
+		head, err := readHead(*fromJSON)
+		if err != nil {
+			return err
+		}
+		return runHead(w, head)
+	}
+	if len(args) > 1 {
+		return runAll(args, w)
+	}
+	repoPath, workTree, err := repoPath(args)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		return err
+	}
+	return runRepo(repoPath, workTree, w)
+}
+
+// repoResult is the outcome for a single repository if multiple are given.
+type repoResult struct {
+	Path    string `json:"path"`
+	Version string `json:"version,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// runAll calls runRepo for each of the repositories at paths and prints the
+// outputs prefixed with the path, or a JSON array with -json. A failing
+// repository is reported without stopping the others, unless -strict is set.
+func runAll(paths []string, w io.Writer) error {
+	// the version of each repository is embedded as string in the JSON output
+	asJSON := *jsonOutput
+	*jsonOutput = false
+	defer func() { *jsonOutput = asJSON }()
+	var results []repoResult
+	failed := 0
+	for _, path := range paths {
+		var buf bytes.Buffer
+		r := repoResult{Path: path}
+		if err := runRepo(path, "", &buf); err != nil {
+			if *strict {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			r.Error = err.Error()
+			failed++
+		} else {
+			r.Version = strings.TrimSpace(buf.String())
+		}
+		results = append(results, r)
+	}
+	if asJSON {
+		if err := json.NewEncoder(w).Encode(results); err != nil {
+			return err
+		}
+	} else {
+		for _, r := range results {
+			if r.Error != "" {
+				fmt.Fprintf(stderr, "%s: %s\n", r.Path, r.Error)
+				continue
+			}
+			fmt.Fprintf(w, "%s: %s\n", r.Path, r.Version)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to derive the version of %d of %d repos", failed, len(paths))
+	}
+	return nil
+}
+
+// runStdin parses the versions read from stdin line by line and prints them in
+// the selected format. Lines that fail are reported with their line number and
+// make runStdin fail after all lines have been processed.
+func runStdin(w io.Writer) error {
+	warnMetaSep()
+	scanner := bufio.NewScanner(stdin)
+	line, failed := 0, 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		s, err := normalize(text)
+		if err != nil {
+			fmt.Fprintf(stderr, "line %d: %s\n", line, err)
+			failed++
+			continue
+		}
+		fmt.Fprintln(w, s)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read stdin: %w", err)
+	}
+	if failed > 0 {
+		return fmt.Errorf("found %d invalid versions", failed)
 	}
+	return nil
+}
+
+// exitCode is returned by run to exit with the given status without printing an
+// error message.
+type exitCode int
+
+func (c exitCode) Error() string {
+	return fmt.Sprintf("exit status %d", int(c))
+}
+
+// runCompare prints the result of comparing the two versions in args, i.e. -1 if
+// the first is lower, 0 if they are equal and 1 if it is higher. The exit status
+// is 0 for equal versions, 2 if the first is lower and 3 if it is higher.
+func runCompare(args []string, w io.Writer) error {
+	if len(args) != 2 {
+		return fmt.Errorf("-compare requires two versions, got %d", len(args))
+	}
+	parse := version.Parse
+	if *strictSemver {
+		parse = version.StrictParse
+	}
+	a, err := parse(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid version %q: %w", args[0], err)
+	}
+	b, err := parse(args[1])
+	if err != nil {
+		return fmt.Errorf("invalid version %q: %w", args[1], err)
+	}
+	c := a.Compare(b)
+	printValue(w, strconv.Itoa(c))
+	switch c {
+	case -1:
+		return exitCode(2)
+	case 1:
+		return exitCode(3)
+	}
+	return nil
+}
+
+// normalize parses the version s and formats it as selected by the command line
+// options.
+func normalize(s string) (string, error) {
+	parse := version.Parse
+	if *strictSemver {
+		parse = version.StrictParse
+	}
+	v, err := parse(s)
+	if err != nil {
+		return "", err
+	}
+	if *stripPrefix {
+		v.Prefix = ""
+	}
+	if *prefix != "" {
+		v.Prefix = *prefix
+	}
+	if *normalizeCase {
+		v = v.Normalize()
+	}
+	return formatVersion(v)
+}
+
+// runRepo prints the output for the repository at repoPath as selected by the
+// command line options. If workTree is set, repoPath is the git directory of the
+// repository and workTree its worktree.
+func runRepo(repoPath, workTree string, w io.Writer) error {
+	opts, err := selectOptions()
+	if err != nil {
+		return err
+	}
+	if workTree != "" {
+		opts = append(opts, version.WithWorkTree(workTree))
+	}
+	if *requireClean {
+		files, err := version.DirtyFiles(repoPath, opts...)
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			fmt.Fprintln(stderr, file)
+		}
+		if len(files) > 0 {
+			return fmt.Errorf("worktree is dirty: %d files changed", len(files))
+		}
+	}
+	if *validateTags {
+		invalid, err := version.ValidateTags(repoPath, opts...)
+		if err != nil {
+			return err
+		}
+		for _, tag := range invalid {
+			fmt.Fprintln(w, tag)
+		}
+		if len(invalid) > 0 {
+			return fmt.Errorf("found %d invalid tags", len(invalid))
+		}
+		return nil
+	}
+	if *count || *totalCommits {
+		if *totalCommits {
+			opts = append(opts, version.WithTotalCommits())
+		}
+		head, err := version.GitDescribe(repoPath, opts...)
+		if err != nil {
+			return err
+		}
+		if *totalCommits {
+			printValue(w, strconv.Itoa(head.TotalCommits))
+		} else {
+			printValue(w, strconv.Itoa(head.CommitsSinceTag))
+		}
+		return nil
+	}
+	if *lastTag {
+		head, err := version.GitDescribe(repoPath, opts...)
+		if err != nil {
+			return err
+		}
+		if head.LastTag == "" && *requireTags {
+			return version.ErrNoTags
+		}
+		printValue(w, head.LastTag)
+		return nil
+	}
+	warnMetaSep()
+	if *export {
+		head, err := version.GitDescribe(repoPath, opts...)
+		if err != nil {
+			return err
+		}
+		dir := repoPath
+		if workTree != "" {
+			dir = workTree
+		}
+		if err := version.WriteArchival(dir, head); err != nil {
+			return err
+		}
+	}
+	if *jsonOutput {
+		opts = append(opts, version.WithTotalCommits())
+	}
+	// head is the describe information that -json prints, which is not
+	// available for -latest and -submodule
+	var head *version.RepoHead
+	var v version.Version
+	switch {
+	case *latest:
+		v, err = version.LatestVersion(repoPath, opts...)
+	case *submodule != "":
+		v, err = version.NewFromSubmodule(repoPath, *submodule, opts...)
+	default:
+		if head, err = describeRepo(repoPath, opts); err == nil {
+			v, err = version.NewFromHead(head, opts...)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if v, err = adjustVersion(v, opts); err != nil {
+		return err
+	}
+	if *assertNewer {
+		if err := assertNewerThanLatest(repoPath, v, opts); err != nil {
+			return err
+		}
+	}
+	if *plan {
+		return printPlan(w, repoPath, v, opts)
+	}
+	if *createTag {
+		return tagRelease(w, repoPath, v, opts)
+	}
+	return printVersion(w, v, head)
+}
+
+// describeRepo returns the describe information of the repository at repoPath as
+// selected by -since-tag and -use-git-binary. Like version.NewFromRepo, it falls
+// back to the archival file if repoPath is not a repository.
+func describeRepo(repoPath string, opts []version.Option) (*version.RepoHead, error) {
+	switch {
+	case *sinceTag != "":
+		return version.GitDescribeSince(repoPath, *sinceTag, opts...)
+	case *useGitBinary:
+		return version.GitBinaryDescriber{}.Describe(repoPath, opts...)
+	}
+	head, err := version.GitDescribe(repoPath, opts...)
+	if errors.Is(err, git.ErrRepositoryNotExists) {
+		if archived, err := version.ReadArchival(repoPath); err == nil {
+			return archived, nil
+		}
+	}
+	return head, err
+}
+
+// assertNewerThanLatest fails if v doesn't have a higher precedence than the
+// highest tag of the repository, e.g. to prevent publishing a release twice.
+func assertNewerThanLatest(repoPath string, v version.Version, opts []version.Option) error {
+	latest, err := version.LatestVersion(repoPath, opts...)
+	if errors.Is(err, version.ErrNoTags) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if v.Compare(latest) <= 0 {
+		return fmt.Errorf("version %s is not newer than the latest tag %s", v, latest)
+	}
+	return nil
+}
+
+// runHead derives the version from the describe information given with -from-tag
+// or -from-json instead of a repository.
+func runHead(w io.Writer, head *version.RepoHead) error {
+	if *plan || *createTag {
+		return errors.New("-plan and -tag require a repository")
+	}
+	opts, err := selectOptions()
+	if err != nil {
+		return err
+	}
+	v, err := version.NewFromHead(head, opts...)
+	if err != nil {
+		return err
+	}
+	if v, err = adjustVersion(v, opts); err != nil {
+		return err
+	}
+	warnMetaSep()
+	return printVersion(w, v, head)
+}
+
+// adjustVersion applies the options that modify the derived version and writes
+// the dotenv file.
+func adjustVersion(v version.Version, opts []version.Option) (version.Version, error) {
+	var err error
 	if *setMeta != "" {
-		v.Meta = *setMeta
+		v.Meta, err = version.ExpandMetaTemplate(*setMeta, v, opts...)
+		if err != nil {
+			return v, err
+		}
 	}
 	if *prefix != "" {
 		v.Prefix = *prefix
 	}
-	s, err := v.Format(selectFormat())
+	if v, err = overrideCore(v); err != nil {
+		return v, err
+	}
+	if *normalizeCase {
+		v = v.Normalize()
+	}
+	if *validate {
+		if err := v.Validate(); err != nil {
+			return v, err
+		}
+	}
+	if *dotenv != "" {
+		if err := v.WriteDotenv(*dotenv); err != nil {
+			return v, err
+		}
+	}
+	return v, nil
+}
+
+// printVersion prints v as selected by -formats, -describe, -template or -format.
+// With -json, head is the describe information v has been derived from, if any.
+func printVersion(w io.Writer, v version.Version, head *version.RepoHead) error {
+	if *formats != "" {
+		return printFormats(w, v)
+	}
+	if *describe {
+		printValue(w, v.Describe())
+		return nil
+	}
+	if *tmpl != "" {
+		s, err := v.Template(*tmpl)
+		if err != nil {
+			return err
+		}
+		printValue(w, s)
+		return nil
+	}
+	if *jsonOutput {
+		s, err := formatVersion(v)
+		if err != nil {
+			return err
+		}
+		return json.NewEncoder(w).Encode(versionOutput{Version: s, RepoHead: head})
+	}
+	f, err := newFormatter(selectFormat())
 	if err != nil {
+		return err
+	}
+	color, err := useColor(w)
+	if err != nil {
+		return err
+	}
+	if color {
+		f = f.WithDecorator(colorize)
+	}
+	s, err := f.Format(v)
+	if err != nil {
+		return err
+	}
+	printValue(w, s)
+	return nil
+}
+
+// versionOutput is the output of -json for a single version. It includes the
+// describe information as returned by git describe, so that it can be read again
+// with -from-json. Options like -commit-offset are applied again then.
+type versionOutput struct {
+	Version string
+	*version.RepoHead
+}
+
+// readHead reads the describe information written by -json or -export from the
+// file at path, or from stdin if path is -.
+func readHead(path string) (*version.RepoHead, error) {
+	r := stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", path, err)
+		}
+		defer f.Close()
+		r = f
+	}
+	var head version.RepoHead
+	if err := json.NewDecoder(r).Decode(&head); err != nil {
+		return nil, fmt.Errorf("failed to decode describe information: %w", err)
+	}
+	return &head, nil
+}
+
+// printValue prints a single value followed by a newline, unless -no-newline is
+// set.
+func printValue(w io.Writer, s string) {
+	if *noNewline {
+		fmt.Fprint(w, s)
+		return
+	}
+	fmt.Fprintln(w, s)
+}
+
+// releasePlan is the output of the -plan option.
+type releasePlan struct {
+	Current string `json:"current"`
+	Next    string `json:"next"`
+	Bump    string `json:"bump"`
+}
+
+// printPlan prints the current version v and the next version as recommended
+// by the conventional commits since the last tag.
+func printPlan(w io.Writer, repoPath string, v version.Version, opts []version.Option) error {
+	bump, err := version.RecommendBump(repoPath, opts...)
+	if err != nil {
+		return err
+	}
+	current, err := formatVersion(v)
+	if err != nil {
+		return err
+	}
+	next, err := formatVersion(v.Bump(bump))
+	if err != nil {
+		return err
+	}
+	if *jsonOutput {
+		return json.NewEncoder(w).Encode(releasePlan{Current: current, Next: next, Bump: bump.String()})
+	}
+	fmt.Fprintf(w, "current=%s\nnext=%s\n", current, next)
+	return nil
+}
+
+// tagRelease creates a tag for the version following v, which is incremented
+// according to -bump or the conventional commits since the last tag.
+func tagRelease(w io.Writer, repoPath string, v version.Version, opts []version.Option) error {
+	var t version.BumpType
+	switch *bump {
+	case "":
+		var err error
+		if t, err = version.RecommendBump(repoPath, opts...); err != nil {
+			return err
+		}
+		if t == version.Invalid {
+			return errors.New("HEAD is already tagged")
+		}
+	case "major":
+		t = version.Major
+	case "minor":
+		t = version.Minor
+	case "patch":
+		t = version.Patch
+	default:
+		return fmt.Errorf("invalid bump type: %s", *bump)
+	}
+	if !*force {
+		files, err := version.DirtyFiles(repoPath, opts...)
+		if err != nil {
+			return err
+		}
+		if len(files) > 0 {
+			return fmt.Errorf("refusing to tag a dirty worktree, use -force to tag anyway: %s", strings.Join(files, ", "))
+		}
+	}
+	var tagOpts []version.Option
+	if *sign {
+		entity, err := readSigningKey(*signingKey)
+		if err != nil {
+			return err
+		}
+		tagOpts = append(tagOpts, version.WithSigningKey(entity))
+	}
+	name := v.Bump(t).String()
+	message := *tagMessage
+	if message == "" {
+		message = "Release " + name
+	}
+	if *dryRun {
+		fmt.Fprintf(stderr, "dry-run: not creating tag %s\n", name)
+	} else if err := version.CreateTag(repoPath, name, message, tagOpts...); err != nil {
+		return err
+	}
+	printValue(w, name)
+	return nil
+}
+
+// readSigningKey reads the first key from the armored key ring in path.
+func readSigningKey(path string) (*openpgp.Entity, error) {
+	if path == "" {
+		return nil, errors.New("-sign requires a key, use -signing-key")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open signing key: %w", err)
+	}
+	defer f.Close()
+	keys, err := openpgp.ReadArmoredKeyRing(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no key found in %s", path)
+	}
+	return keys[0], nil
+}
+
+// namedFormats are the names of the predefined formats that can be passed to
+// -formats.
+var namedFormats = map[string]string{
+	"full":     version.FullFormat,
+	"no-meta":  version.NoMetaFormat,
+	"core":     version.NoPreFormat,
+	"no-patch": version.NoPatchFormat,
+	"major":    version.NoMinorFormat,
+	"rc":       version.ReleaseCandidate,
+}
+
+// printFormats prints v in each of the comma-separated formats given with
+// -formats, labeled by the name or the format string.
+func printFormats(w io.Writer, v version.Version) error {
+	var labels []string
+	values := map[string]string{}
+	for _, label := range strings.Split(*formats, ",") {
+		label = strings.TrimSpace(label)
+		format, ok := namedFormats[label]
+		if !ok {
+			format = label
+		}
+		s, err := formatVersionAs(v, format)
+		if err != nil {
+			return err
+		}
+		if _, ok := values[label]; !ok {
+			labels = append(labels, label)
+		}
+		values[label] = s
+	}
+	if *jsonOutput {
+		return json.NewEncoder(w).Encode(values)
+	}
+	for _, label := range labels {
+		fmt.Fprintf(w, "%s=%s\n", label, values[label])
+	}
+	return nil
+}
+
+func main() {
+	flag.Parse()
+	if err := run(flag.Args(), os.Stdout); err != nil {
+		var code exitCode
+		if errors.As(err, &code) {
+			os.Exit(int(code))
+		}
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
-	fmt.Println(s)
 }
@@ -0,0 +1,965 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/mantyr/git-semver/v6/version"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+)
+
+// runWithFlags parses the command line args and calls run with the remaining
+// positional arguments. All flags are reset to their defaults afterwards.
+func runWithFlags(t *testing.T, args ...string) (string, error) {
+	defer flag.VisitAll(func(f *flag.Flag) {
+		if !strings.HasPrefix(f.Name, "test.") {
+			_ = f.Value.Set(f.DefValue)
+		}
+	})
+	assert.NoError(t, flag.CommandLine.Parse(args))
+	var out bytes.Buffer
+	err := run(flag.Args(), &out)
+	return out.String(), err
+}
+
+// newRepo creates a repository with a single commit that is tagged with each of
+// the given tags.
+func newRepo(t *testing.T, tags ...string) string {
+	dir, err := ioutil.TempDir("", "git-semver")
+	assert.NoError(t, err)
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(t, err)
+	worktree, err := repo.Worktree()
+	assert.NoError(t, err)
+	commit, err := worktree.Commit("first commit", &git.CommitOptions{Author: &object.Signature{
+		Name:  "John Doe",
+		Email: "john@doe.org",
+		When:  time.Now(),
+	}})
+	assert.NoError(t, err)
+	for _, tag := range tags {
+		_, err = repo.CreateTag(tag, commit, nil)
+		assert.NoError(t, err)
+	}
+	return dir
+}
+
+// addCommits adds a commit for each of the messages to the repository at dir.
+func addCommits(t *testing.T, dir string, messages ...string) {
+	repo, err := git.PlainOpen(dir)
+	assert.NoError(t, err)
+	worktree, err := repo.Worktree()
+	assert.NoError(t, err)
+	for i, message := range messages {
+		_, err = worktree.Commit(message, &git.CommitOptions{Author: &object.Signature{
+			Name:  "John Doe",
+			Email: "john@doe.org",
+			When:  time.Now().Add(time.Duration(i+1) * time.Minute),
+		}})
+		assert.NoError(t, err)
+	}
+}
+
+func TestRun(t *testing.T) {
+	assert := assert.New(t)
+	dir := newRepo(t, "v1.2.3")
+	defer os.RemoveAll(dir)
+
+	out, err := runWithFlags(t, dir)
+	assert.NoError(err)
+	assert.Equal("v1.2.3\n", out)
+
+	out, err = runWithFlags(t, "-no-patch", "-strip-prefix", dir)
+	assert.NoError(err)
+	assert.Equal("1.2\n", out)
+}
+
+func TestRunGitDir(t *testing.T) {
+	assert := assert.New(t)
+	dir := newRepo(t, "v1.2.3")
+	defer os.RemoveAll(dir)
+	other := newRepo(t, "v2.0.0")
+	defer os.RemoveAll(other)
+
+	os.Setenv("GIT_DIR", filepath.Join(dir, ".git"))
+	defer os.Unsetenv("GIT_DIR")
+	out, err := runWithFlags(t)
+	assert.NoError(err)
+	assert.Equal("v1.2.3\n", out)
+
+	out, err = runWithFlags(t, other)
+	assert.NoError(err)
+	assert.Equal("v2.0.0\n", out)
+
+	os.Unsetenv("GIT_DIR")
+	os.Setenv("GIT_WORK_TREE", other)
+	defer os.Unsetenv("GIT_WORK_TREE")
+	out, err = runWithFlags(t)
+	assert.NoError(err)
+	assert.Equal("v2.0.0\n", out)
+}
+
+func TestRunSemverRepoEnv(t *testing.T) {
+	assert := assert.New(t)
+	dir := newRepo(t, "v1.2.3")
+	defer os.RemoveAll(dir)
+	other := newRepo(t, "v2.0.0")
+	defer os.RemoveAll(other)
+
+	os.Setenv("GIT_SEMVER_REPO", dir)
+	defer os.Unsetenv("GIT_SEMVER_REPO")
+	os.Setenv("GIT_WORK_TREE", other)
+	defer os.Unsetenv("GIT_WORK_TREE")
+	out, err := runWithFlags(t)
+	assert.NoError(err)
+	assert.Equal("v1.2.3\n", out)
+
+	out, err = runWithFlags(t, other)
+	assert.NoError(err)
+	assert.Equal("v2.0.0\n", out)
+}
+
+func TestRunGitDirEnv(t *testing.T) {
+	assert := assert.New(t)
+	defer func(w io.Writer) { stderr = w }(stderr)
+	stderr = ioutil.Discard
+	dir := newRepo(t, "v1.2.3")
+	defer os.RemoveAll(dir)
+	workTree, err := ioutil.TempDir("", "git-semver")
+	assert.NoError(err)
+	defer os.RemoveAll(workTree)
+	assert.NoError(ioutil.WriteFile(filepath.Join(dir, "file.txt"), []byte("content"), 0644))
+	repo, err := git.PlainOpen(dir)
+	assert.NoError(err)
+	worktree, err := repo.Worktree()
+	assert.NoError(err)
+	_, err = worktree.Add("file.txt")
+	assert.NoError(err)
+	addCommits(t, dir, "add file")
+	// the default worktree in dir is dirty, whereas the one selected by
+	// GIT_WORK_TREE is clean
+	assert.NoError(ioutil.WriteFile(filepath.Join(dir, "file.txt"), []byte("changed"), 0644))
+	assert.NoError(ioutil.WriteFile(filepath.Join(workTree, "file.txt"), []byte("content"), 0644))
+
+	os.Setenv("GIT_DIR", filepath.Join(dir, ".git"))
+	defer os.Unsetenv("GIT_DIR")
+	os.Setenv("GIT_WORK_TREE", workTree)
+	defer os.Unsetenv("GIT_WORK_TREE")
+	out, err := runWithFlags(t, "-require-clean", "-no-meta")
+	assert.NoError(err)
+	assert.Equal("v1.2.4-dev.1\n", out)
+
+	assert.NoError(ioutil.WriteFile(filepath.Join(workTree, "file.txt"), []byte("changed"), 0644))
+	_, err = runWithFlags(t, "-require-clean")
+	assert.EqualError(err, "worktree is dirty: 1 files changed")
+}
+
+func TestRunIgnoreTag(t *testing.T) {
+	assert := assert.New(t)
+	dir := newRepo(t, "v1.2.3", "v9.9.9")
+	defer os.RemoveAll(dir)
+
+	out, err := runWithFlags(t, "-ignore-tag", "v9.9.9", dir)
+	assert.NoError(err)
+	assert.Equal("v1.2.3\n", out)
+	assert.Empty(ignoreTags)
+
+	out, err = runWithFlags(t, dir)
+	assert.NoError(err)
+	assert.Equal("v9.9.9\n", out)
+}
+
+func TestRunCount(t *testing.T) {
+	assert := assert.New(t)
+	dir := newRepo(t, "v1.2.3")
+	defer os.RemoveAll(dir)
+	out, err := runWithFlags(t, "-count", dir)
+	assert.NoError(err)
+	assert.Equal("0\n", out)
+
+	untagged := newRepo(t)
+	defer os.RemoveAll(untagged)
+	out, err = runWithFlags(t, "-count", untagged)
+	assert.NoError(err)
+	assert.Equal("1\n", out)
+	out, err = runWithFlags(t, "-total-commits", untagged)
+	assert.NoError(err)
+	assert.Equal("1\n", out)
+
+	addCommits(t, dir, "fix: typo", "fix: another typo")
+	out, err = runWithFlags(t, "-count", dir)
+	assert.NoError(err)
+	assert.Equal("2\n", out)
+	out, err = runWithFlags(t, "-total-commits", dir)
+	assert.NoError(err)
+	assert.Equal("3\n", out)
+	out, err = runWithFlags(t, "-count", "-ignore-tag", "v1.2.3", dir)
+	assert.NoError(err)
+	assert.Equal("3\n", out)
+}
+
+func TestRunLastTag(t *testing.T) {
+	assert := assert.New(t)
+	dir := newRepo(t, "v1.2.3")
+	defer os.RemoveAll(dir)
+	addCommits(t, dir, "fix: typo")
+	out, err := runWithFlags(t, "-last-tag", dir)
+	assert.NoError(err)
+	assert.Equal("v1.2.3\n", out)
+
+	nonSemver := newRepo(t, "release-2020")
+	defer os.RemoveAll(nonSemver)
+	out, err = runWithFlags(t, "-last-tag", nonSemver)
+	assert.NoError(err)
+	assert.Equal("release-2020\n", out)
+
+	untagged := newRepo(t)
+	defer os.RemoveAll(untagged)
+	out, err = runWithFlags(t, "-last-tag", untagged)
+	assert.NoError(err)
+	assert.Equal("\n", out)
+	_, err = runWithFlags(t, "-last-tag", "-require-tags", untagged)
+	assert.True(errors.Is(err, version.ErrNoTags))
+}
+
+func TestRunValidate(t *testing.T) {
+	assert := assert.New(t)
+	dir := newRepo(t, "v1.2.3")
+	defer os.RemoveAll(dir)
+	out, err := runWithFlags(t, "-validate", "-set-meta", "build.1", dir)
+	assert.NoError(err)
+	assert.Equal("v1.2.3+build.1\n", out)
+
+	invalid := newRepo(t, "1.2.3-rc_1")
+	defer os.RemoveAll(invalid)
+	out, err = runWithFlags(t, invalid)
+	assert.NoError(err)
+	assert.Equal("1.2.3-rc_1\n", out)
+	out, err = runWithFlags(t, "-validate", invalid)
+	assert.Error(err)
+	assert.Empty(out)
+}
+
+func TestRunPlan(t *testing.T) {
+	assert := assert.New(t)
+	dir := newRepo(t, "v1.2.3")
+	defer os.RemoveAll(dir)
+
+	out, err := runWithFlags(t, "-plan", dir)
+	assert.NoError(err)
+	assert.Equal("current=v1.2.3\nnext=v1.2.3\n", out)
+
+	addCommits(t, dir, "fix: handle empty tags", "feat: add -plan option")
+	out, err = runWithFlags(t, "-plan", "-no-meta", dir)
+	assert.NoError(err)
+	assert.Equal("current=v1.2.4-dev.2\nnext=v1.3.0\n", out)
+
+	out, err = runWithFlags(t, "-plan", "-json", "-strip-prefix", "-no-meta", dir)
+	assert.NoError(err)
+	assert.JSONEq(`{"current":"1.2.4-dev.2","next":"1.3.0","bump":"minor"}`, out)
+
+	// the tag of another component on HEAD doesn't affect the plan
+	repo, err := git.PlainOpen(dir)
+	assert.NoError(err)
+	head, err := repo.Head()
+	assert.NoError(err)
+	_, err = repo.CreateTag("web/v1.0.0", head.Hash(), nil)
+	assert.NoError(err)
+	out, err = runWithFlags(t, "-plan", "-no-meta", "-match-regex", "^v", dir)
+	assert.NoError(err)
+	assert.Equal("current=v1.2.4-dev.2\nnext=v1.3.0\n", out)
+}
+
+func TestRunFormats(t *testing.T) {
+	assert := assert.New(t)
+	dir := newRepo(t, "v1.2.3")
+	defer os.RemoveAll(dir)
+	addCommits(t, dir, "fix: typo")
+	v, err := version.NewFromRepo(dir)
+	assert.NoError(err)
+
+	out, err := runWithFlags(t, "-formats", "full,no-meta,x_y", dir)
+	assert.NoError(err)
+	assert.Equal("full=v1.2.4-dev.1+"+v.Meta+"\nno-meta=v1.2.4-dev.1\nx_y=v1_2\n", out)
+
+	out, err = runWithFlags(t, "-formats", "core, rc, x", "-json", dir)
+	assert.NoError(err)
+	assert.JSONEq(`{"core":"v1.2.4","rc":"v1.2.4-rc.1","x":"v1"}`, out)
+
+	_, err = runWithFlags(t, "-formats", "full,invalid", dir)
+	assert.Error(err)
+}
+
+func TestRunAbbrev(t *testing.T) {
+	assert := assert.New(t)
+	for _, test := range []struct {
+		args []string
+		meta string
+	}{
+		{nil, "fcf2c8fa"},
+		{[]string{"-short"}, "fcf2c8f"},
+		{[]string{"-abbrev", "10"}, "fcf2c8fa52"},
+		{[]string{"-short", "-abbrev", "12"}, "fcf2c8fa52f6"},
+		{[]string{"-set-meta", "{hash}"}, "fcf2c8fa"},
+		{[]string{"-set-meta", "{hash}", "-abbrev", "10"}, "fcf2c8fa52"},
+		{[]string{"-set-meta", "{commits}.{hash}", "-short", "-git-hash-prefix"}, "3.gfcf2c8f"},
+	} {
+		args := append([]string{"-from-tag", "1.2.3", "-commits", "3", "-hash", "fcf2c8fa52f6e1c3"}, test.args...)
+		out, err := runWithFlags(t, args...)
+		assert.NoError(err)
+		assert.Equal("1.2.4-dev.3+"+test.meta+"\n", out)
+	}
+}
+
+func TestRunDateSource(t *testing.T) {
+	assert := assert.New(t)
+	dir := newRepo(t, "v1.2.3")
+	defer os.RemoveAll(dir)
+
+	out, err := runWithFlags(t, "-format", "x.y.z+d", "-date-source", "author", dir)
+	assert.NoError(err)
+	assert.Regexp(`^v1\.2\.3\+[0-9]{14}\n$`, out)
+
+	_, err = runWithFlags(t, "-date-source", "tagger", dir)
+	assert.Error(err)
+}
+
+func TestRunMatchRegex(t *testing.T) {
+	assert := assert.New(t)
+	dir := newRepo(t, "api/v1.2.3", "web/v2.0.0")
+	defer os.RemoveAll(dir)
+
+	out, err := runWithFlags(t, "-match-regex", "^api/(.+)$", dir)
+	assert.NoError(err)
+	assert.Equal("api/v1.2.3\n", out)
+
+	_, err = runWithFlags(t, "-match-regex", "^api/(", dir)
+	assert.Error(err)
+}
+
+func TestRunNoNewline(t *testing.T) {
+	assert := assert.New(t)
+	dir := newRepo(t, "v1.2.3")
+	defer os.RemoveAll(dir)
+
+	out, err := runWithFlags(t, "-n", dir)
+	assert.NoError(err)
+	assert.Equal("v1.2.3", out)
+
+	out, err = runWithFlags(t, "-no-newline", "-describe", dir)
+	assert.NoError(err)
+	assert.Equal("v1.2.3", out)
+
+	out, err = runWithFlags(t, "-n", "-count", dir)
+	assert.NoError(err)
+	assert.Equal("0", out)
+}
+
+func TestRunExport(t *testing.T) {
+	assert := assert.New(t)
+	dir := newRepo(t, "v1.2.3")
+	defer os.RemoveAll(dir)
+
+	out, err := runWithFlags(t, "-export", dir)
+	assert.NoError(err)
+	assert.Equal("v1.2.3\n", out)
+
+	assert.NoError(os.RemoveAll(filepath.Join(dir, ".git")))
+	out, err = runWithFlags(t, "-describe", dir)
+	assert.NoError(err)
+	assert.Equal("v1.2.3\n", out)
+}
+
+func TestRunMetaSep(t *testing.T) {
+	assert := assert.New(t)
+	dir := newRepo(t, "v1.2.3")
+	defer os.RemoveAll(dir)
+
+	out, err := runWithFlags(t, "-meta-sep", "_", "-set-meta", "build.1", dir)
+	assert.NoError(err)
+	assert.Equal("v1.2.3_build.1\n", out)
+
+	out, err = runWithFlags(t, "-meta-sep", ".", "-set-meta", "build.1", dir)
+	assert.NoError(err)
+	assert.Equal("v1.2.3.build.1\n", out)
+}
+
+func TestRunMetaSepWarning(t *testing.T) {
+	assert := assert.New(t)
+	defer func(r io.Reader, w io.Writer) { stdin, stderr = r, w }(stdin, stderr)
+	var errOut bytes.Buffer
+	stderr = &errOut
+	const warning = "warning: the output is no valid SemVer with -meta-sep\n"
+
+	out, err := runWithFlags(t, "-meta-sep", "_", "-from-tag", "v1.2.3+build.1")
+	assert.NoError(err)
+	assert.Equal("v1.2.3_build.1\n", out)
+	assert.Equal(warning, errOut.String())
+
+	errOut.Reset()
+	stdin = strings.NewReader("1.2.3+build.1\n")
+	out, err = runWithFlags(t, "-meta-sep", "_", "-stdin")
+	assert.NoError(err)
+	assert.Equal("1.2.3_build.1\n", out)
+	assert.Equal(warning, errOut.String())
+
+	errOut.Reset()
+	out, err = runWithFlags(t, "-from-tag", "v1.2.3+build.1")
+	assert.NoError(err)
+	assert.Equal("v1.2.3+build.1\n", out)
+	assert.Empty(errOut.String())
+}
+
+func TestRunRequireTags(t *testing.T) {
+	assert := assert.New(t)
+	dir := newRepo(t)
+	defer os.RemoveAll(dir)
+
+	_, err := runWithFlags(t, dir)
+	assert.NoError(err)
+	_, err = runWithFlags(t, "-require-tags", dir)
+	assert.True(errors.Is(err, version.ErrNoTags))
+}
+
+func TestRunMultipleRepos(t *testing.T) {
+	assert := assert.New(t)
+	api := newRepo(t, "v1.2.3")
+	defer os.RemoveAll(api)
+	web := newRepo(t, "v2.0.0")
+	defer os.RemoveAll(web)
+
+	out, err := runWithFlags(t, api, web)
+	assert.NoError(err)
+	assert.Equal(api+": v1.2.3\n"+web+": v2.0.0\n", out)
+
+	out, err = runWithFlags(t, "-json", "-strip-prefix", api, web)
+	assert.NoError(err)
+	assert.JSONEq(`[{"path":"`+api+`","version":"1.2.3"},{"path":"`+web+`","version":"2.0.0"}]`, out)
+
+	missing := filepath.Join(api, "missing")
+	out, err = runWithFlags(t, api, missing, web)
+	assert.Error(err)
+	assert.Equal(api+": v1.2.3\n"+web+": v2.0.0\n", out)
+
+	out, err = runWithFlags(t, "-strict", api, missing, web)
+	assert.Error(err)
+	assert.Empty(out)
+}
+
+func TestRunTemplate(t *testing.T) {
+	assert := assert.New(t)
+	dir := newRepo(t, "v1.2.3")
+	defer os.RemoveAll(dir)
+
+	out, err := runWithFlags(t, "-template", "{{.Major}}.{{.Minor}} ({{.Commits}} commits)", dir)
+	assert.NoError(err)
+	assert.Equal("1.2 (0 commits)\n", out)
+
+	_, err = runWithFlags(t, "-template", "{{.Major", dir)
+	assert.Error(err)
+}
+
+func TestRunStdin(t *testing.T) {
+	assert := assert.New(t)
+	defer func(r io.Reader, w io.Writer) { stdin, stderr = r, w }(stdin, stderr)
+	var errOut bytes.Buffer
+	stdin = strings.NewReader("v1.2.3\n1.2.3-rc.1+build.5\n\n1.2\nv2.0.0-rc..1\n  3.0.0  \n")
+	stderr = &errOut
+
+	out, err := runWithFlags(t, "-stdin", "-no-meta")
+	assert.EqualError(err, "found 2 invalid versions")
+	assert.Equal("v1.2.3\n1.2.3-rc.1\n3.0.0\n", out)
+	assert.Contains(errOut.String(), "line 4: ")
+	assert.Contains(errOut.String(), "line 5: ")
+
+	stdin = strings.NewReader("v1.2.3\n1.2.4\n")
+	out, err = runWithFlags(t, "-stdin", "-strip-prefix")
+	assert.NoError(err)
+	assert.Equal("1.2.3\n1.2.4\n", out)
+
+	errOut.Reset()
+	stdin = strings.NewReader("v1.2.3\n1.2.4\n")
+	out, err = runWithFlags(t, "-stdin", "-strict-semver")
+	assert.EqualError(err, "found 1 invalid versions")
+	assert.Equal("1.2.4\n", out)
+	assert.Equal("line 1: version v1.2.3 has the prefix v, which is not allowed by SemVer\n", errOut.String())
+
+	errOut.Reset()
+	stdin = strings.NewReader("01.2.3\n1.02.3\n1.2.3+a_b\n1.2.3\n1.2.3+a+b\n1.2.3-\n1.2.3+\n")
+	out, err = runWithFlags(t, "-stdin")
+	assert.EqualError(err, "found 6 invalid versions")
+	assert.Equal("1.2.3\n", out)
+	for _, line := range []string{"line 1: ", "line 2: ", "line 3: ", "line 5: ", "line 6: ", "line 7: "} {
+		assert.Contains(errOut.String(), line)
+	}
+}
+
+func TestRunMainBranch(t *testing.T) {
+	assert := assert.New(t)
+	dir := newRepo(t, "v1.2.3")
+	defer os.RemoveAll(dir)
+	repo, err := git.PlainOpen(dir)
+	assert.NoError(err)
+	worktree, err := repo.Worktree()
+	assert.NoError(err)
+	assert.NoError(worktree.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("trunk"), Create: true}))
+	addCommits(t, dir, "commit on trunk")
+
+	out, err := runWithFlags(t, "-branch-prerelease", "-no-meta", dir)
+	assert.NoError(err)
+	assert.Equal("v1.2.4-trunk.dev.1\n", out)
+
+	out, err = runWithFlags(t, "-branch-prerelease", "-main-branch", "trunk", "-no-meta", dir)
+	assert.NoError(err)
+	assert.Equal("v1.2.4-dev.1\n", out)
+
+	assert.NoError(worktree.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("feature/x"), Create: true}))
+	out, err = runWithFlags(t, "-branch-prerelease", "-main-branch", "trunk", "-no-meta", dir)
+	assert.NoError(err)
+	assert.Equal("v1.2.4-feature-x.dev.1\n", out)
+}
+
+func TestRunSetCore(t *testing.T) {
+	assert := assert.New(t)
+	dir := newRepo(t, "v1.2.3")
+	defer os.RemoveAll(dir)
+	addCommits(t, dir, "fix: hotfix")
+
+	for _, test := range []struct {
+		args []string
+		out  string
+	}{
+		{nil, "v1.2.4-dev.1\n"},
+		{[]string{"-set-major", "3"}, "v3.2.4-dev.1\n"},
+		{[]string{"-set-minor", "0"}, "v1.0.4-dev.1\n"},
+		{[]string{"-set-patch", "9"}, "v1.2.9-dev.1\n"},
+		{[]string{"-set-major", "2", "-set-minor", "1", "-set-patch", "0"}, "v2.1.0-dev.1\n"},
+	} {
+		out, err := runWithFlags(t, append(append([]string{"-no-meta"}, test.args...), dir)...)
+		assert.NoError(err)
+		assert.Equal(test.out, out, test.args)
+	}
+
+	_, err := runWithFlags(t, "-set-minor", "-2", dir)
+	assert.Error(err)
+}
+
+func TestRunCache(t *testing.T) {
+	assert := assert.New(t)
+	dir := newRepo(t, "v1.2.3")
+	defer os.RemoveAll(dir)
+	cache := filepath.Join(dir, ".git", version.CacheFile)
+
+	out, err := runWithFlags(t, dir)
+	assert.NoError(err)
+	assert.Equal("v1.2.3\n", out)
+	_, err = os.Stat(cache)
+	assert.True(os.IsNotExist(err))
+
+	out, err = runWithFlags(t, "-cache", dir)
+	assert.NoError(err)
+	assert.Equal("v1.2.3\n", out)
+	assert.FileExists(cache)
+
+	_, err = runWithFlags(t, "-cache", "-use-git-binary", dir)
+	assert.EqualError(err, "-cache is not supported with -use-git-binary")
+}
+
+func TestRunTag(t *testing.T) {
+	assert := assert.New(t)
+	defer func(w io.Writer) { stderr = w }(stderr)
+	stderr = ioutil.Discard
+	dir := newRepo(t, "v1.2.3")
+	defer os.RemoveAll(dir)
+
+	_, err := runWithFlags(t, "-tag", dir)
+	assert.EqualError(err, "HEAD is already tagged")
+
+	addCommits(t, dir, "feat: add option")
+	out, err := runWithFlags(t, "-tag", "-dry-run", dir)
+	assert.NoError(err)
+	assert.Equal("v1.3.0\n", out)
+	repo, err := git.PlainOpen(dir)
+	assert.NoError(err)
+	_, err = repo.Tag("v1.3.0")
+	assert.Equal(git.ErrTagNotFound, err)
+
+	assert.NoError(ioutil.WriteFile(filepath.Join(dir, "dirty.txt"), []byte("dirty"), 0644))
+	_, err = runWithFlags(t, "-tag", dir)
+	assert.EqualError(err, "refusing to tag a dirty worktree, use -force to tag anyway: dirty.txt")
+	out, err = runWithFlags(t, "-tag", "-dry-run", "-ignore-untracked", dir)
+	assert.NoError(err)
+	assert.Equal("v1.3.0\n", out)
+
+	out, err = runWithFlags(t, "-tag", "-force", "-bump", "major", "-message", "Major release", dir)
+	assert.NoError(err)
+	assert.Equal("v2.0.0\n", out)
+	ref, err := repo.Tag("v2.0.0")
+	assert.NoError(err)
+	tag, err := repo.TagObject(ref.Hash())
+	assert.NoError(err)
+	assert.Equal("Major release\n", tag.Message)
+
+	out, err = runWithFlags(t, dir)
+	assert.NoError(err)
+	assert.Equal("v2.0.0\n", out)
+}
+
+func TestRunTagComponent(t *testing.T) {
+	assert := assert.New(t)
+	dir := newRepo(t, "api/v1.2.3")
+	defer os.RemoveAll(dir)
+	addCommits(t, dir, "feat: add endpoint")
+	repo, err := git.PlainOpen(dir)
+	assert.NoError(err)
+	head, err := repo.Head()
+	assert.NoError(err)
+	_, err = repo.CreateTag("web/v1.0.0", head.Hash(), nil)
+	assert.NoError(err)
+
+	out, err := runWithFlags(t, "-tag", "-dry-run", "-component", "api", dir)
+	assert.NoError(err)
+	assert.Equal("api/v1.3.0\n", out)
+}
+
+func TestRunTagSigned(t *testing.T) {
+	assert := assert.New(t)
+	dir := newRepo(t, "v1.2.3")
+	defer os.RemoveAll(dir)
+	addCommits(t, dir, "fix: typo")
+
+	_, err := runWithFlags(t, "-tag", "-sign", dir)
+	assert.EqualError(err, "-sign requires a key, use -signing-key")
+
+	entity, err := openpgp.NewEntity("John Doe", "", "john@doe.org", nil)
+	assert.NoError(err)
+	keyFile := filepath.Join(dir, ".git", "key.asc")
+	f, err := os.Create(keyFile)
+	assert.NoError(err)
+	w, err := armor.Encode(f, openpgp.PrivateKeyType, nil)
+	assert.NoError(err)
+	assert.NoError(entity.SerializePrivate(w, nil))
+	assert.NoError(w.Close())
+	assert.NoError(f.Close())
+
+	out, err := runWithFlags(t, "-tag", "-sign", "-signing-key", keyFile, dir)
+	assert.NoError(err)
+	assert.Equal("v1.2.4\n", out)
+	repo, err := git.PlainOpen(dir)
+	assert.NoError(err)
+	ref, err := repo.Tag("v1.2.4")
+	assert.NoError(err)
+	tag, err := repo.TagObject(ref.Hash())
+	assert.NoError(err)
+	assert.NotEmpty(tag.PGPSignature)
+}
+
+func TestRunFromTag(t *testing.T) {
+	assert := assert.New(t)
+	out, err := runWithFlags(t, "-from-tag", "v1.2.3", "-commits", "4", "-no-meta")
+	assert.NoError(err)
+	assert.Equal("v1.2.4-dev.4\n", out)
+
+	out, err = runWithFlags(t, "-from-tag", "v1.2.3", "-commits", "4", "-hash", "fcf2c8fa52f6e1c3", "-strip-prefix")
+	assert.NoError(err)
+	assert.Equal("1.2.4-dev.4+fcf2c8fa\n", out)
+
+	out, err = runWithFlags(t, "-from-tag", "1.2.3-rc.1", "-describe", "-hash", "fcf2c8fa52f6e1c3", "-commits", "2")
+	assert.NoError(err)
+	assert.Equal("1.2.3-rc.1-2-gfcf2c8f\n", out)
+
+	_, err = runWithFlags(t, "-from-tag", "1.2")
+	assert.Error(err)
+	_, err = runWithFlags(t, "-from-tag", "1.2.3", "-plan")
+	assert.Error(err)
+}
+
+func TestRunLatest(t *testing.T) {
+	assert := assert.New(t)
+	dir := newRepo(t, "v1.2.3")
+	defer os.RemoveAll(dir)
+	addCommits(t, dir, "feat: new feature")
+	repo, err := git.PlainOpen(dir)
+	assert.NoError(err)
+	head, err := repo.Head()
+	assert.NoError(err)
+	_, err = repo.CreateTag("v1.3.0", head.Hash(), nil)
+	assert.NoError(err)
+	first, err := repo.Tag("v1.2.3")
+	assert.NoError(err)
+	worktree, err := repo.Worktree()
+	assert.NoError(err)
+	assert.NoError(worktree.Checkout(&git.CheckoutOptions{Hash: first.Hash()}))
+
+	out, err := runWithFlags(t, dir)
+	assert.NoError(err)
+	assert.Equal("v1.2.3\n", out)
+	out, err = runWithFlags(t, "-latest", dir)
+	assert.NoError(err)
+	assert.Equal("v1.3.0\n", out)
+}
+
+func TestRunFromJSON(t *testing.T) {
+	assert := assert.New(t)
+	dir := newRepo(t, "v1.2.3-rc.1")
+	defer os.RemoveAll(dir)
+	addCommits(t, dir, "fix: typo", "fix: another typo")
+	expected, err := runWithFlags(t, dir)
+	assert.NoError(err)
+	describe, err := runWithFlags(t, "-describe", dir)
+	assert.NoError(err)
+
+	captured, err := runWithFlags(t, "-json", dir)
+	assert.NoError(err)
+	assert.Contains(captured, `"Version":"`+strings.TrimSpace(expected)+`"`)
+	file := filepath.Join(dir, ".git", "version.json")
+	assert.NoError(ioutil.WriteFile(file, []byte(captured), 0644))
+
+	out, err := runWithFlags(t, "-from-json", file)
+	assert.NoError(err)
+	assert.Equal(expected, out)
+	out, err = runWithFlags(t, "-from-json", file, "-describe")
+	assert.NoError(err)
+	assert.Equal(describe, out)
+	out, err = runWithFlags(t, "-from-json", file, "-format", "x.y.z", "-strip-prefix")
+	assert.NoError(err)
+	assert.Equal("1.2.3\n", out)
+
+	defer func(r io.Reader) { stdin = r }(stdin)
+	stdin = strings.NewReader(captured)
+	out, err = runWithFlags(t, "-from-json", "-", "-no-meta")
+	assert.NoError(err)
+	assert.Equal("v1.2.3-rc.1.dev.2\n", out)
+
+	_, err = runWithFlags(t, "-from-json", filepath.Join(dir, "missing.json"))
+	assert.Error(err)
+}
+
+func TestRunJSONRoundTrip(t *testing.T) {
+	assert := assert.New(t)
+	dir := newRepo(t, "v1.2.3")
+	defer os.RemoveAll(dir)
+	addCommits(t, dir, "fix: typo", "fix: another typo", "fix: last typo")
+	file := filepath.Join(dir, ".git", "version.json")
+
+	for _, args := range [][]string{
+		nil,
+		{"-commit-offset", "5"},
+		{"-build-number", "42"},
+	} {
+		expected, err := runWithFlags(t, append(args, dir)...)
+		assert.NoError(err)
+		captured, err := runWithFlags(t, append(append([]string{"-json"}, args...), dir)...)
+		assert.NoError(err)
+		var output struct {
+			Version         string
+			Tags            []string
+			CommitsSinceTag int
+			TotalCommits    int
+			Branch          string
+		}
+		assert.NoError(json.Unmarshal([]byte(captured), &output))
+		assert.Equal(strings.TrimSpace(expected), output.Version, args)
+		assert.Equal([]string{"v1.2.3"}, output.Tags, args)
+		assert.Equal(3, output.CommitsSinceTag, args)
+		assert.Equal(4, output.TotalCommits, args)
+		assert.Equal("master", output.Branch, args)
+
+		assert.NoError(ioutil.WriteFile(file, []byte(captured), 0644))
+		out, err := runWithFlags(t, append([]string{"-from-json", file}, args...)...)
+		assert.NoError(err)
+		assert.Equal(expected, out, args)
+	}
+
+	// the version of the latest tag has no describe information
+	out, err := runWithFlags(t, "-json", "-latest", dir)
+	assert.NoError(err)
+	assert.JSONEq(`{"Version":"v1.2.3"}`, out)
+}
+
+func TestRunAssertNewer(t *testing.T) {
+	assert := assert.New(t)
+	dir := newRepo(t, "v1.2.3")
+	defer os.RemoveAll(dir)
+
+	_, err := runWithFlags(t, "-assert-newer", dir)
+	assert.EqualError(err, "version v1.2.3 is not newer than the latest tag v1.2.3")
+
+	addCommits(t, dir, "fix: bug")
+	out, err := runWithFlags(t, "-assert-newer", "-no-meta", dir)
+	assert.NoError(err)
+	assert.Equal("v1.2.4-dev.1\n", out)
+
+	repo, err := git.PlainOpen(dir)
+	assert.NoError(err)
+	head, err := repo.Head()
+	assert.NoError(err)
+	_, err = repo.CreateTag("v1.3.0", head.Hash(), nil)
+	assert.NoError(err)
+	first, err := repo.Tag("v1.2.3")
+	assert.NoError(err)
+	worktree, err := repo.Worktree()
+	assert.NoError(err)
+	assert.NoError(worktree.Checkout(&git.CheckoutOptions{Hash: first.Hash()}))
+	_, err = runWithFlags(t, "-assert-newer", dir)
+	assert.EqualError(err, "version v1.2.3 is not newer than the latest tag v1.3.0")
+
+	untagged := newRepo(t)
+	defer os.RemoveAll(untagged)
+	_, err = runWithFlags(t, "-assert-newer", untagged)
+	assert.NoError(err)
+}
+
+func TestRunColor(t *testing.T) {
+	assert := assert.New(t)
+	dir := newRepo(t, "v1.2.3-rc.1")
+	defer os.RemoveAll(dir)
+
+	out, err := runWithFlags(t, dir)
+	assert.NoError(err)
+	assert.Equal("v1.2.3-rc.1\n", out)
+	assert.NotContains(out, "\x1b[")
+
+	out, err = runWithFlags(t, "-color", "always", dir)
+	assert.NoError(err)
+	assert.Equal("v\x1b[31m1\x1b[0m.\x1b[33m2\x1b[0m.\x1b[32m3\x1b[0m-\x1b[36mrc.1\x1b[0m\n", out)
+
+	out, err = runWithFlags(t, "-color", "always", "-json", dir)
+	assert.NoError(err)
+	assert.NotContains(out, "\x1b[")
+
+	_, err = runWithFlags(t, "-color", "sometimes", dir)
+	assert.EqualError(err, "invalid color mode: sometimes")
+
+	f, err := ioutil.TempFile("", "git-semver")
+	assert.NoError(err)
+	defer os.Remove(f.Name())
+	defer f.Close()
+	assert.False(isTerminal(f))
+	color, err := useColor(f)
+	assert.NoError(err)
+	assert.False(color)
+}
+
+func TestRunRequireClean(t *testing.T) {
+	assert := assert.New(t)
+	defer func(w io.Writer) { stderr = w }(stderr)
+	var errOut bytes.Buffer
+	stderr = &errOut
+	dir := newRepo(t, "v1.2.3")
+	defer os.RemoveAll(dir)
+
+	out, err := runWithFlags(t, "-require-clean", dir)
+	assert.NoError(err)
+	assert.Equal("v1.2.3\n", out)
+	assert.Empty(errOut.String())
+
+	assert.NoError(ioutil.WriteFile(filepath.Join(dir, "dirty.txt"), []byte("dirty"), 0644))
+	out, err = runWithFlags(t, "-require-clean", dir)
+	assert.EqualError(err, "worktree is dirty: 1 files changed")
+	assert.Empty(out)
+	assert.Equal("dirty.txt\n", errOut.String())
+
+	errOut.Reset()
+	out, err = runWithFlags(t, "-require-clean", "-ignore-untracked", dir)
+	assert.NoError(err)
+	assert.Equal("v1.2.3\n", out)
+	assert.Empty(errOut.String())
+}
+
+func TestRunDevSeparator(t *testing.T) {
+	assert := assert.New(t)
+	dir := newRepo(t, "v1.2.3-rc.1")
+	defer os.RemoveAll(dir)
+	addCommits(t, dir, "fix: bug")
+
+	out, err := runWithFlags(t, "-no-meta", dir)
+	assert.NoError(err)
+	assert.Equal("v1.2.3-rc.1.dev.1\n", out)
+
+	out, err = runWithFlags(t, "-no-meta", "-dev-separator", "-", dir)
+	assert.NoError(err)
+	assert.Equal("v1.2.3-rc.1-dev.1\n", out)
+
+	_, err = runWithFlags(t, "-dev-separator", "_", dir)
+	assert.EqualError(err, `invalid dev separator "_": must be a dot or consist of alphanumerics and hyphens`)
+}
+
+func TestRunCompare(t *testing.T) {
+	assert := assert.New(t)
+	for _, test := range []struct {
+		a, b string
+		out  string
+		code exitCode
+	}{
+		{"1.2.3", "1.3.0", "-1\n", 2},
+		{"v1.2.3", "1.2.3+build.5", "0\n", 0},
+		{"2.0.0", "2.0.0-rc.1", "1\n", 3},
+	} {
+		out, err := runWithFlags(t, "-compare", test.a, test.b)
+		assert.Equal(test.out, out)
+		if test.code == 0 {
+			assert.NoError(err)
+			continue
+		}
+		var code exitCode
+		assert.True(errors.As(err, &code))
+		assert.Equal(test.code, code)
+	}
+
+	_, err := runWithFlags(t, "-compare", "1.2.3", "1.2")
+	assert.EqualError(err, `invalid version "1.2": git version tag must contain 3 components: X.Y.Z: Got 1.2`)
+	var code exitCode
+	assert.False(errors.As(err, &code))
+	_, err = runWithFlags(t, "-compare", "01.2.3", "1.2.3")
+	assert.EqualError(err, `invalid version "01.2.3": numeric version component "01" has leading zeros in 01.2.3`)
+	assert.False(errors.As(err, &code))
+	_, err = runWithFlags(t, "-compare", "1.2.3")
+	assert.EqualError(err, "-compare requires two versions, got 1")
+}
+
+func TestRunBuildNumber(t *testing.T) {
+	assert := assert.New(t)
+	dir := newRepo(t, "v1.2.3")
+	defer os.RemoveAll(dir)
+
+	out, err := runWithFlags(t, "-build-number", "42", dir)
+	assert.NoError(err)
+	assert.Equal("v1.2.3\n", out)
+	out, err = runWithFlags(t, "-build-number", "42", "-force-pre", "-no-meta", dir)
+	assert.NoError(err)
+	assert.Equal("v1.2.4-dev.42\n", out)
+
+	addCommits(t, dir, "fix: bug")
+	out, err = runWithFlags(t, "-build-number", "42", "-no-meta", dir)
+	assert.NoError(err)
+	assert.Equal("v1.2.4-dev.42\n", out)
+}
+
+func TestRunNormalize(t *testing.T) {
+	assert := assert.New(t)
+	dir := newRepo(t, "v1.2.3-RC.1+Build.5")
+	defer os.RemoveAll(dir)
+
+	out, err := runWithFlags(t, dir)
+	assert.NoError(err)
+	assert.Equal("v1.2.3-RC.1+Build.5\n", out)
+
+	out, err = runWithFlags(t, "-normalize", dir)
+	assert.NoError(err)
+	assert.Equal("v1.2.3-rc.1+build.5\n", out)
+}
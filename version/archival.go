@@ -0,0 +1,56 @@
+package version
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// ArchivalFile is the name of the file that WriteArchival creates in the root of
+// a repository. It allows to derive the version from exported source archives,
+// which don't contain the git history.
+const ArchivalFile = ".git-semver"
+
+// WriteArchival writes the describe information of head as JSON to the
+// ArchivalFile in the directory at path.
+func WriteArchival(path string, head *RepoHead) error {
+	data, err := json.MarshalIndent(head, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode archival file: %w", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(path, ArchivalFile), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write archival file: %w", err)
+	}
+	return nil
+}
+
+// ReadArchival reads the describe information from the ArchivalFile in the
+// directory at path.
+func ReadArchival(path string) (*RepoHead, error) {
+	data, err := ioutil.ReadFile(filepath.Join(path, ArchivalFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archival file: %w", err)
+	}
+	var head RepoHead
+	if err := json.Unmarshal(data, &head); err != nil {
+		return nil, fmt.Errorf("failed to decode archival file: %w", err)
+	}
+	return &head, nil
+}
+
+// NewFromArchival derives the version from the ArchivalFile in the directory at
+// path instead of the git history.
+func NewFromArchival(path string, opts ...Option) (Version, error) {
+	head, err := ReadArchival(path)
+	if err != nil {
+		return Version{}, err
+	}
+	return NewFromHead(head, opts...)
+}
+
+func hasArchival(path string) bool {
+	_, err := os.Stat(filepath.Join(path, ArchivalFile))
+	return err == nil
+}
@@ -0,0 +1,59 @@
+package version
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArchival(t *testing.T) {
+	assert := assert.New(t)
+	dir, _ := ioutil.TempDir("", "example")
+	defer os.RemoveAll(dir)
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(err)
+	worktree, err := repo.Worktree()
+	assert.NoError(err)
+	for i := 0; i < 3; i++ {
+		commit, err := worktree.Commit("commit", &git.CommitOptions{Author: &object.Signature{
+			Name:  "John Doe",
+			Email: "john@doe.org",
+			When:  time.Unix(int64(i), 0),
+		}})
+		assert.NoError(err)
+		if i == 0 {
+			_, err = repo.CreateTag("v1.2.3", commit, nil)
+			assert.NoError(err)
+		}
+	}
+	expected, err := NewFromRepo(dir)
+	assert.NoError(err)
+
+	head, err := GitDescribe(dir)
+	assert.NoError(err)
+	export, _ := ioutil.TempDir("", "export")
+	defer os.RemoveAll(export)
+	assert.NoError(WriteArchival(export, head))
+
+	v, err := NewFromArchival(export)
+	assert.NoError(err)
+	assert.Equal(expected.String(), v.String())
+	assert.Equal(expected.Describe(), v.Describe())
+	assert.True(expected.CommitTime.Equal(v.CommitTime))
+
+	v, err = NewFromRepo(export, WithoutPrefix())
+	assert.NoError(err)
+	assert.Equal(expected.WithPrefix("").String(), v.String())
+
+	assert.NoError(os.Remove(filepath.Join(export, ArchivalFile)))
+	_, err = NewFromRepo(export)
+	assert.Error(err)
+	_, err = NewFromArchival(export)
+	assert.Error(err)
+}
@@ -0,0 +1,166 @@
+package version
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// BumpType denotes the version component that is incremented by Bump.
+type BumpType int
+
+// Bump types that can be passed to Bump. The zero value Invalid doesn't change the
+// version.
+const (
+	Invalid BumpType = iota
+	Major
+	Minor
+	Patch
+)
+
+func (t BumpType) String() string {
+	switch t {
+	case Major:
+		return "major"
+	case Minor:
+		return "minor"
+	case Patch:
+		return "patch"
+	}
+	return "invalid"
+}
+
+// Bump increments the version component denoted by t. See BumpMajor, BumpMinor
+// and BumpPatch for details. An Invalid bump type returns the version unchanged.
+func (v Version) Bump(t BumpType) Version {
+	switch t {
+	case Major:
+		return v.BumpMajor()
+	case Minor:
+		return v.BumpMinor()
+	case Patch:
+		return v.BumpPatch()
+	}
+	return v
+}
+
+// BumpMajor returns the next major release, e.g. 1.2.3 becomes 2.0.0. A major
+// pre-release like 2.0.0-rc.1 becomes 2.0.0. The pre-release, commits and metadata
+// are removed.
+func (v Version) BumpMajor() Version {
+	r := v.release()
+	if v.PreRelease() == "" || r.Minor != 0 || r.Patch != 0 {
+		r.Major++
+		r.Minor = 0
+		r.Patch = 0
+	}
+	return r
+}
+
+// BumpMinor returns the next minor release, e.g. 1.2.3 becomes 1.3.0. A minor
+// pre-release like 1.3.0-rc.1 becomes 1.3.0. The pre-release, commits and metadata
+// are removed.
+func (v Version) BumpMinor() Version {
+	r := v.release()
+	if v.PreRelease() == "" || r.Patch != 0 {
+		r.Minor++
+		r.Patch = 0
+	}
+	return r
+}
+
+// BumpPatch returns the next patch release, e.g. 1.2.3 becomes 1.2.4. A
+// pre-release like 1.2.4-rc.1 or 1.2.4-dev.3 becomes 1.2.4. The pre-release,
+// commits and metadata are removed.
+func (v Version) BumpPatch() Version {
+	r := v.release()
+	if v.PreRelease() == "" {
+		r.Patch++
+	}
+	return r
+}
+
+// Precision denotes the last version component that is kept by Truncate. It is
+// an alias of BumpType, so that Major, Minor and Patch can be used.
+type Precision = BumpType
+
+// Truncate returns the release of the version with all components after the one
+// denoted by precision set to zero, e.g. 1.2.3 becomes 1.0.0 for Major and 1.2.0
+// for Minor, which is useful to group artifacts by major or minor line. Like the
+// bumps, the pre-release, commits and metadata are removed, but the patch version
+// of a commit after a tag is incremented. An Invalid precision returns the
+// version unchanged.
+func (v Version) Truncate(precision Precision) Version {
+	r := v.release()
+	switch precision {
+	case Major:
+		r.Minor = 0
+		r.Patch = 0
+	case Minor:
+		r.Patch = 0
+	case Patch:
+	default:
+		return v
+	}
+	return r
+}
+
+// IncrementPreRelease returns a copy of the version with the trailing numeric
+// identifier of the pre-release incremented, e.g. rc.1 becomes rc.2. If the last
+// identifier isn't numeric, .1 is appended, so that beta becomes beta.1. All
+// other components including the commits since the tag are kept. An error is
+// returned if the version has no valid pre-release.
+func (v Version) IncrementPreRelease() (Version, error) {
+	if v.preRelease == "" {
+		return v, errors.New("version has no pre-release")
+	}
+	if err := validatePreRelease(v.preRelease); err != nil {
+		return v, err
+	}
+	ids := strings.Split(v.preRelease, ".")
+	last := len(ids) - 1
+	if n, err := strconv.Atoi(ids[last]); err == nil {
+		ids[last] = strconv.Itoa(n + 1)
+	} else {
+		ids = append(ids, "1")
+	}
+	v.preRelease = strings.Join(ids, ".")
+	return v, nil
+}
+
+// NextPreRelease returns the next pre-release of the given channel like alpha,
+// beta or rc. If the pre-release of v already belongs to the channel, its number
+// is incremented, e.g. 1.2.3-rc.1 becomes 1.2.3-rc.2. Otherwise the channel starts
+// at 1, so that 1.2.3-beta.2 and the development version 1.2.4-dev.3 become
+// 1.2.3-rc.1 and 1.2.4-rc.1. For a release the channel starts at the next patch
+// version. The commits and metadata are removed.
+func (v Version) NextPreRelease(channel string) (Version, error) {
+	if channel == "" || strings.Contains(channel, ".") || validatePreRelease(channel) != nil {
+		return v, fmt.Errorf("invalid pre-release channel %q", channel)
+	}
+	r := v.BumpPatch()
+	r.preRelease = channel + ".1"
+	if v.preRelease == channel || strings.HasPrefix(v.preRelease, channel+".") {
+		next, err := Version{preRelease: v.preRelease}.IncrementPreRelease()
+		if err != nil {
+			return v, err
+		}
+		r.preRelease = next.preRelease
+	}
+	return r, nil
+}
+
+// release returns the version with the same core version as v as it would be
+// formatted, but without pre-release, commits and metadata. The patch version is
+// incremented for commits after a tag even if WithoutAutoIncrement is used, since
+// the result would otherwise collide with the tag.
+func (v Version) release() Version {
+	v.noIncrement = false
+	return Version{
+		Prefix: v.Prefix,
+		Major:  v.Major,
+		Minor:  v.Minor,
+		Patch:  v.effectivePatch(),
+	}
+}
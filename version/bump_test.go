@@ -0,0 +1,113 @@
+package version
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBump(t *testing.T) {
+	assert := assert.New(t)
+	for _, test := range []struct {
+		v Version
+		t BumpType
+		s string
+	}{
+		{Version{Major: 1, Minor: 2, Patch: 3}, Major, "2.0.0"},
+		{Version{Major: 1, Minor: 2, Patch: 3}, Minor, "1.3.0"},
+		{Version{Major: 1, Minor: 2, Patch: 3}, Patch, "1.2.4"},
+		{Version{Major: 1, Minor: 2, Patch: 3}, Invalid, "1.2.3"},
+		{Version{Prefix: "v", Major: 1, Minor: 2, Patch: 3, Commits: 3, Meta: "fcf2c8fa"}, Patch, "v1.2.4"},
+		{Version{Prefix: "v", Major: 1, Minor: 2, Patch: 3, Commits: 3, Meta: "fcf2c8fa"}, Minor, "v1.3.0"},
+		{Version{Major: 1, Minor: 2, Patch: 3, preRelease: "rc.1"}, Patch, "1.2.3"},
+		{Version{Major: 1, Minor: 2, Patch: 3, preRelease: "rc.1"}, Minor, "1.3.0"},
+		{Version{Major: 1, Minor: 3, Patch: 0, preRelease: "rc.1"}, Minor, "1.3.0"},
+		{Version{Major: 2, Minor: 0, Patch: 0, preRelease: "rc.1", Commits: 2}, Major, "2.0.0"},
+		{Version{Major: 2, Minor: 1, Patch: 0, preRelease: "rc.1"}, Major, "3.0.0"},
+		{Version{Major: 1, Minor: 2, Patch: 3, Commits: 3, noIncrement: true}, Patch, "1.2.4"},
+	} {
+		assert.Equal(test.s, test.v.Bump(test.t).String(), "%s %s", test.v, test.t)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	assert := assert.New(t)
+	for _, test := range []struct {
+		v Version
+		p Precision
+		s string
+	}{
+		{Version{Prefix: "v", Major: 1, Minor: 2, Patch: 3}, Major, "v1.0.0"},
+		{Version{Prefix: "v", Major: 1, Minor: 2, Patch: 3}, Minor, "v1.2.0"},
+		{Version{Prefix: "v", Major: 1, Minor: 2, Patch: 3}, Patch, "v1.2.3"},
+		{Version{Prefix: "v", Major: 1, Minor: 2, Patch: 3}, Invalid, "v1.2.3"},
+		{Version{Major: 1, Minor: 2, Patch: 3, preRelease: "rc.1", Meta: "fcf2c8fa"}, Major, "1.0.0"},
+		{Version{Major: 1, Minor: 2, Patch: 3, preRelease: "rc.1", Meta: "fcf2c8fa"}, Minor, "1.2.0"},
+		{Version{Major: 1, Minor: 2, Patch: 3, preRelease: "rc.1", Meta: "fcf2c8fa"}, Patch, "1.2.3"},
+		{Version{Major: 1, Minor: 2, Patch: 3, Commits: 3, Meta: "fcf2c8fa"}, Patch, "1.2.4"},
+		{Version{Major: 1, Minor: 2, Patch: 3, Commits: 3, Meta: "fcf2c8fa"}, Minor, "1.2.0"},
+	} {
+		assert.Equal(test.s, test.v.Truncate(test.p).String(), "%s %s", test.v, test.p)
+	}
+	v, err := Version{Major: 1, Minor: 2, Patch: 3}.Truncate(Minor).Format(NoPatchFormat)
+	assert.NoError(err)
+	assert.Equal("1.2", v)
+}
+
+func TestBumpTypeString(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("invalid", Invalid.String())
+	assert.Equal("major", Major.String())
+	assert.Equal("minor", Minor.String())
+	assert.Equal("patch", Patch.String())
+	assert.Equal("invalid", BumpType(42).String())
+}
+
+func TestIncrementPreRelease(t *testing.T) {
+	assert := assert.New(t)
+	for _, test := range []struct {
+		pre      string
+		expected string
+	}{
+		{"rc.1", "1.2.3-rc.2"},
+		{"beta", "1.2.3-beta.1"},
+		{"alpha.1.2", "1.2.3-alpha.1.3"},
+		{"rc.9", "1.2.3-rc.10"},
+		{"1", "1.2.3-2"},
+	} {
+		v, err := Version{Major: 1, Minor: 2, Patch: 3, preRelease: test.pre}.IncrementPreRelease()
+		assert.NoError(err)
+		assert.Equal(test.expected, v.String())
+	}
+
+	_, err := Version{Major: 1, Minor: 2, Patch: 3}.IncrementPreRelease()
+	assert.Error(err)
+	_, err = Version{Major: 1, Minor: 2, Patch: 3, preRelease: "rc..1"}.IncrementPreRelease()
+	assert.Error(err)
+}
+
+func TestNextPreRelease(t *testing.T) {
+	assert := assert.New(t)
+	for _, test := range []struct {
+		v        Version
+		channel  string
+		expected string
+	}{
+		{Version{Major: 1, Minor: 2, Patch: 3, Commits: 3, Meta: "fcf2c8fa"}, "rc", "1.2.4-rc.1"},
+		{Version{Prefix: "v", Major: 1, Minor: 2, Patch: 3, preRelease: "rc.1"}, "rc", "v1.2.3-rc.2"},
+		{Version{Major: 1, Minor: 2, Patch: 3, preRelease: "rc.1", Commits: 2}, "rc", "1.2.3-rc.2"},
+		{Version{Major: 1, Minor: 2, Patch: 3, preRelease: "beta.2"}, "rc", "1.2.3-rc.1"},
+		{Version{Major: 1, Minor: 2, Patch: 3, preRelease: "beta"}, "beta", "1.2.3-beta.1"},
+		{Version{Major: 1, Minor: 2, Patch: 3, preRelease: "rcx.1"}, "rc", "1.2.3-rc.1"},
+		{Version{Major: 1, Minor: 2, Patch: 3}, "alpha", "1.2.4-alpha.1"},
+	} {
+		v, err := test.v.NextPreRelease(test.channel)
+		assert.NoError(err)
+		assert.Equal(test.expected, v.String(), "%s %s", test.v, test.channel)
+	}
+
+	for _, channel := range []string{"", "rc.1", "rc_1"} {
+		_, err := Version{Major: 1}.NextPreRelease(channel)
+		assert.Error(err, channel)
+	}
+}
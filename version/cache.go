@@ -0,0 +1,99 @@
+package version
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+)
+
+// CacheFile is the name of the file in the git directory that stores the cached
+// result of GitDescribe.
+const CacheFile = "git-semver-cache"
+
+// cacheFormat is part of the cache key and has to be incremented whenever the
+// fields of RepoHead change, so that outdated entries are not used.
+const cacheFormat = 3
+
+type cacheEntry struct {
+	Key  string   `json:"key"`
+	Head RepoHead `json:"head"`
+}
+
+// cachedDescribe returns the cached RepoHead if its key matches the current state
+// of the repository and describes the repository otherwise. Errors while reading
+// or writing the cache are ignored, since the cache is only an optimization.
+func cachedDescribe(repo *git.Repository, o *options) (*RepoHead, error) {
+	s, ok := repo.Storer.(*filesystem.Storage)
+	if !ok {
+		return describe(repo, o)
+	}
+	key, err := cacheKey(repo, o)
+	if err != nil {
+		return describe(repo, o)
+	}
+	path := filepath.Join(s.Filesystem().Root(), CacheFile)
+	if data, err := ioutil.ReadFile(path); err == nil {
+		var entry cacheEntry
+		if json.Unmarshal(data, &entry) == nil && entry.Key == key {
+			return &entry.Head, nil
+		}
+	}
+	head, err := describe(repo, o)
+	if err != nil {
+		return nil, err
+	}
+	if data, err := json.Marshal(cacheEntry{Key: key, Head: *head}); err == nil {
+		_ = ioutil.WriteFile(path, data, 0644)
+	}
+	return head, nil
+}
+
+// cacheKey hashes the HEAD commit, all tag references and the options, so that
+// any change invalidates the cache. All options are part of the key, so that new
+// ones can't be forgotten, except for those that can't be hashed and don't change
+// the result of GitDescribe.
+func cacheKey(repo *git.Repository, o *options) (string, error) {
+	head, err := repo.Head()
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve repo head: %w", err)
+	}
+	refs, err := repo.References()
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve references: %w", err)
+	}
+	var tags []string
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Name().IsTag() || (o.allTags && ref.Name().IsRemote()) {
+			tags = append(tags, ref.Name().String()+" "+ref.Hash().String())
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve references: %w", err)
+	}
+	sort.Strings(tags)
+
+	h := sha1.New()
+	fmt.Fprintln(h, cacheFormat)
+	fmt.Fprintln(h, head.Name(), head.Hash())
+	for _, tag := range tags {
+		fmt.Fprintln(h, tag)
+	}
+	var pattern string
+	if o.matchRegex != nil {
+		pattern = o.matchRegex.String()
+	}
+	// the regexp is hashed by its pattern, maps are printed with sorted keys
+	k := *o
+	k.matchRegex, k.signKey, k.classifier, k.cache = nil, nil, nil, false
+	fmt.Fprintf(h, "%q %#v\n", pattern, k)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
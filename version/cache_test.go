@@ -0,0 +1,163 @@
+package version
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGitDescribeCache(t *testing.T) {
+	assert := assert.New(t)
+	calls := 0
+	describe = func(repo *git.Repository, o *options) (*RepoHead, error) {
+		calls++
+		return describeRepository(repo, o)
+	}
+	defer func() { describe = describeRepository }()
+
+	dir, _ := ioutil.TempDir("", "example")
+	defer os.RemoveAll(dir)
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(err)
+	worktree, err := repo.Worktree()
+	assert.NoError(err)
+	commit := func(i int) {
+		hash, err := worktree.Commit("commit", &git.CommitOptions{Author: &object.Signature{
+			Name:  "John Doe",
+			Email: "john@doe.org",
+			When:  time.Unix(int64(i), 0),
+		}})
+		assert.NoError(err)
+		if i == 0 {
+			_, err = repo.CreateTag("v1.2.3", hash, nil)
+			assert.NoError(err)
+		}
+	}
+	commit(0)
+	commit(1)
+
+	head, err := GitDescribe(dir, WithCache())
+	assert.NoError(err)
+	assert.Equal(1, head.CommitsSinceTag)
+	assert.FileExists(filepath.Join(dir, ".git", CacheFile))
+	cached, err := GitDescribe(dir, WithCache())
+	assert.NoError(err)
+	assert.Equal(1, calls)
+	assert.Equal(head.LastTag, cached.LastTag)
+	assert.Equal(head.CommitsSinceTag, cached.CommitsSinceTag)
+	assert.True(head.CommitTime.Equal(cached.CommitTime))
+
+	_, err = GitDescribe(dir, WithCache(), WithMatchRegex(regexp.MustCompile(`^v`)))
+	assert.NoError(err)
+	assert.Equal(2, calls)
+
+	commit(2)
+	head, err = GitDescribe(dir, WithCache())
+	assert.NoError(err)
+	assert.Equal(3, calls)
+	assert.Equal(2, head.CommitsSinceTag)
+
+	_, err = GitDescribe(dir)
+	assert.NoError(err)
+	assert.Equal(4, calls)
+}
+
+func TestGitDescribeCacheOptions(t *testing.T) {
+	assert := assert.New(t)
+	dir, _ := ioutil.TempDir("", "example")
+	defer os.RemoveAll(dir)
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(err)
+	worktree, err := repo.Worktree()
+	assert.NoError(err)
+	for i, tag := range []string{"v1.0.0", "v9.9.9", "nightly"} {
+		hash, err := worktree.Commit("commit", &git.CommitOptions{Author: &object.Signature{
+			Name:  "John Doe",
+			Email: "john@doe.org",
+			When:  time.Unix(int64(i), 0),
+		}})
+		assert.NoError(err)
+		_, err = repo.CreateTag(tag, hash, nil)
+		assert.NoError(err)
+	}
+
+	// the cache written for one set of options must not be used for another
+	head, err := GitDescribe(dir, WithCache(), WithSkipNonSemverTags(), WithStrictSemver())
+	assert.NoError(err)
+	assert.Equal("", head.LastTag)
+	head, err = GitDescribe(dir, WithCache(), WithSkipNonSemverTags())
+	assert.NoError(err)
+	assert.Equal("v9.9.9", head.LastTag)
+	head, err = GitDescribe(dir, WithCache(), WithSkipNonSemverTags(), WithStrictSemver())
+	assert.NoError(err)
+	assert.Equal("", head.LastTag)
+}
+
+func TestCacheKey(t *testing.T) {
+	assert := assert.New(t)
+	dir, _ := ioutil.TempDir("", "example")
+	defer os.RemoveAll(dir)
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(err)
+	worktree, err := repo.Worktree()
+	assert.NoError(err)
+	_, err = worktree.Commit("commit", &git.CommitOptions{Author: &object.Signature{
+		Name:  "John Doe",
+		Email: "john@doe.org",
+		When:  time.Unix(0, 0),
+	}})
+	assert.NoError(err)
+
+	base, err := cacheKey(repo, newOptions([]Option{WithCache()}))
+	assert.NoError(err)
+	same, err := cacheKey(repo, newOptions([]Option{WithCache(), WithMatchRegex(regexp.MustCompile(`^v`))}))
+	assert.NoError(err)
+	again, err := cacheKey(repo, newOptions([]Option{WithCache(), WithMatchRegex(regexp.MustCompile(`^v`))}))
+	assert.NoError(err)
+	assert.Equal(same, again)
+
+	for i, opt := range []Option{
+		WithBranchPreRelease(),
+		WithMainBranch("trunk"),
+		WithoutPrefix(),
+		WithStrictTags(),
+		WithoutAutoIncrement(),
+		WithAuthorDate(),
+		WithStableTagsOnly(),
+		WithMatchRegex(regexp.MustCompile(`^v`)),
+		WithShortTags(),
+		WithRequiredTags(),
+		WithAllTags(),
+		WithAppendHash(),
+		WithSkipNonSemverTags(),
+		WithCommitOffset(1),
+		WithBuildNumber(1),
+		WithForcePreRelease(),
+		WithGitHashPrefix(),
+		WithMetaCommits(),
+		WithAbbrev(12),
+		WithComponent("api"),
+		WithIgnoreTags("v1.0.0"),
+		WithIgnoreUntracked(),
+		WithStrictSemver(),
+		WithDirtyMark("-modified"),
+		WithDevSeparator("-"),
+		WithWorkTree(dir),
+	} {
+		key, err := cacheKey(repo, newOptions([]Option{WithCache(), opt}))
+		assert.NoError(err)
+		assert.NotEqual(base, key, "option %d", i)
+	}
+	key, err := cacheKey(repo, newOptions([]Option{WithCache(), WithBumpClassifier(func(message string) BumpType {
+		return Patch
+	})}))
+	assert.NoError(err)
+	assert.Equal(base, key)
+}
@@ -0,0 +1,203 @@
+package version
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type comparison struct {
+	op string
+	v  Version
+}
+
+func (c comparison) check(v Version) bool {
+	r := v.Compare(c.v)
+	switch c.op {
+	case "=":
+		return r == 0
+	case "!=":
+		return r != 0
+	case ">":
+		return r > 0
+	case ">=":
+		return r >= 0
+	case "<":
+		return r < 0
+	case "<=":
+		return r <= 0
+	}
+	return false
+}
+
+// Constraint restricts versions to one or more ranges. A range consists of space
+// separated comparisons that all have to be satisfied, e.g. ">=1.2.0 <2.0.0".
+// Alternative ranges are separated by "||", e.g. "1.2.x || 2.0.x". The following
+// comparisons are supported:
+// * 1.2.3, =1.2.3 -> equal to 1.2.3
+// * !=1.2.3 -> not equal to 1.2.3
+// * >1.2.3, >=1.2.3, <1.2.3, <=1.2.3 -> greater (or equal) / less (or equal) than 1.2.3
+// * 1.2.x, 1.2, 1.x, * -> any version with the given major (and minor) version
+// * ~1.2.3 -> >=1.2.3 <1.3.0
+// * ^1.2.3 -> >=1.2.3 <2.0.0, ^0.2.3 -> >=0.2.3 <0.3.0
+type Constraint struct {
+	raw    string
+	groups [][]comparison
+}
+
+// ParseConstraint parses a constraint string as described for Constraint.
+func ParseConstraint(s string) (Constraint, error) {
+	c := Constraint{raw: s}
+	for _, group := range strings.Split(s, "||") {
+		var comparisons []comparison
+		fields := strings.Fields(group)
+		for i := 0; i < len(fields); i++ {
+			f := fields[i]
+			if isOperator(f) && i+1 < len(fields) {
+				i++
+				f += fields[i]
+			}
+			cs, err := parseComparison(f)
+			if err != nil {
+				return Constraint{}, fmt.Errorf("invalid constraint %q: %w", s, err)
+			}
+			comparisons = append(comparisons, cs...)
+		}
+		if len(fields) == 0 {
+			return Constraint{}, fmt.Errorf("invalid constraint %q: empty range", s)
+		}
+		c.groups = append(c.groups, comparisons)
+	}
+	return c, nil
+}
+
+// Check reports whether v satisfies the constraint, i.e. at least one of the
+// ranges that are separated by "||".
+func (c Constraint) Check(v Version) bool {
+	return c.CheckAny(v)
+}
+
+// CheckAny reports whether v satisfies at least one of the ranges of the
+// constraint that are separated by "||". It is the same as Check.
+func (c Constraint) CheckAny(v Version) bool {
+	for _, g := range c.groups {
+		if checkAll(g, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c Constraint) String() string {
+	return c.raw
+}
+
+func checkAll(comparisons []comparison, v Version) bool {
+	for _, c := range comparisons {
+		if !c.check(v) {
+			return false
+		}
+	}
+	return true
+}
+
+var operators = []string{">=", "<=", "!=", "=", ">", "<", "~", "^"}
+
+func isOperator(s string) bool {
+	for _, op := range operators {
+		if s == op {
+			return true
+		}
+	}
+	return false
+}
+
+var partialVersionRe = regexp.MustCompile(
+	`^v?(x|X|\*|[0-9]+)(?:\.(x|X|\*|[0-9]+))?(?:\.(x|X|\*|[0-9]+))?(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+
+// parseComparison parses a single comparison like >=1.2 and expands it into the
+// comparisons of complete versions it is equivalent to.
+func parseComparison(s string) ([]comparison, error) {
+	var op string
+	for _, o := range operators {
+		if strings.HasPrefix(s, o) {
+			op = o
+			break
+		}
+	}
+	m := partialVersionRe.FindStringSubmatch(strings.TrimPrefix(s, op))
+	if m == nil {
+		return nil, fmt.Errorf("invalid version in %s", s)
+	}
+	var parts [3]int
+	n := 0
+	for ; n < 3; n++ {
+		if m[n+1] == "" || m[n+1] == "x" || m[n+1] == "X" || m[n+1] == "*" {
+			break
+		}
+		parts[n], _ = strconv.Atoi(m[n+1])
+	}
+	if m[4] != "" && n < 3 {
+		return nil, fmt.Errorf("pre-release requires a complete version in %s", s)
+	}
+	lower := Version{Major: parts[0], Minor: parts[1], Patch: parts[2], preRelease: m[4]}
+	next := func(n int) Version {
+		switch n {
+		case 1:
+			return Version{Major: parts[0] + 1, preRelease: "0"}
+		case 2:
+			return Version{Major: parts[0], Minor: parts[1] + 1, preRelease: "0"}
+		}
+		return Version{Major: parts[0], Minor: parts[1], Patch: parts[2] + 1, preRelease: "0"}
+	}
+
+	if n == 0 {
+		if op == "" || op == "=" || op == ">=" || op == "<=" {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("wildcard not allowed with %s", op)
+	}
+	switch op {
+	case "", "=":
+		if n == 3 {
+			return []comparison{{"=", lower}}, nil
+		}
+		return []comparison{{">=", lower}, {"<", next(n)}}, nil
+	case "!=":
+		if n < 3 {
+			return nil, fmt.Errorf("partial version not allowed with %s", op)
+		}
+		return []comparison{{"!=", lower}}, nil
+	case ">":
+		if n == 3 {
+			return []comparison{{">", lower}}, nil
+		}
+		return []comparison{{">=", next(n)}}, nil
+	case ">=":
+		return []comparison{{">=", lower}}, nil
+	case "<":
+		if n < 3 {
+			lower.preRelease = "0"
+		}
+		return []comparison{{"<", lower}}, nil
+	case "<=":
+		if n == 3 {
+			return []comparison{{"<=", lower}}, nil
+		}
+		return []comparison{{"<", next(n)}}, nil
+	case "~":
+		if n == 1 {
+			return []comparison{{">=", lower}, {"<", next(1)}}, nil
+		}
+		return []comparison{{">=", lower}, {"<", next(2)}}, nil
+	}
+	// op == "^"
+	switch {
+	case parts[0] > 0 || n == 1:
+		return []comparison{{">=", lower}, {"<", next(1)}}, nil
+	case parts[1] > 0 || n == 2:
+		return []comparison{{">=", lower}, {"<", next(2)}}, nil
+	}
+	return []comparison{{">=", lower}, {"<", next(3)}}, nil
+}
@@ -0,0 +1,96 @@
+package version
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConstraint(t *testing.T) {
+	assert := assert.New(t)
+	for _, test := range []struct {
+		c     string
+		v     string
+		check bool
+	}{
+		{"1.2.3", "1.2.3", true},
+		{"=1.2.3", "v1.2.3", true},
+		{"1.2.3", "1.2.4", false},
+		{"!=1.2.3", "1.2.4", true},
+		{">1.2.3", "1.2.4", true},
+		{">1.2.3", "1.2.3", false},
+		{">= 1.2.3", "1.2.3", true},
+		{"<1.2.3", "1.2.3-rc.1", true},
+		{"<=1.2.3", "1.2.3", true},
+		{">1.2", "1.2.9", false},
+		{">1.2", "1.3.0", true},
+		{"<=1.2", "1.2.9", true},
+		{"<1.2", "1.2.0-rc.1", false},
+		{"1.2.x", "1.2.7", true},
+		{"1.2.x", "1.3.0-rc.1", false},
+		{"1.x", "1.9.9", true},
+		{"*", "0.0.1", true},
+		{"~1.2.3", "1.2.9", true},
+		{"~1.2.3", "1.3.0", false},
+		{"^1.2.3", "1.9.0", true},
+		{"^1.2.3", "2.0.0", false},
+		{"^0.2.3", "0.2.9", true},
+		{"^0.2.3", "0.3.0", false},
+		{"^0.0.3", "0.0.4", false},
+		{">=1.2.0 <2.0.0", "1.5.0", true},
+		{">=1.2.0 <2.0.0", "2.0.0", false},
+	} {
+		c, err := ParseConstraint(test.c)
+		assert.NoError(err)
+		v, err := Parse(test.v)
+		assert.NoError(err)
+		assert.Equal(test.check, c.Check(v), "%s %s", test.c, test.v)
+		assert.Equal(test.check, c.CheckAny(v), "%s %s", test.c, test.v)
+	}
+}
+
+func TestConstraintAny(t *testing.T) {
+	assert := assert.New(t)
+	c, err := ParseConstraint("1.2.x || 2.0.x")
+	assert.NoError(err)
+	assert.Equal("1.2.x || 2.0.x", c.String())
+	for _, test := range []struct {
+		v     string
+		match bool
+	}{
+		{"1.2.3", true},
+		{"2.0.1", true},
+		{"1.3.0", false},
+		{"2.1.0", false},
+	} {
+		v, err := Parse(test.v)
+		assert.NoError(err)
+		assert.Equal(test.match, c.CheckAny(v), test.v)
+		assert.Equal(test.match, c.Check(v), test.v)
+	}
+
+	c, err = ParseConstraint(">=1.0.0 <1.5.0 || >=2.0.0 <3.0.0")
+	assert.NoError(err)
+	v, _ := Parse("2.1.0")
+	assert.True(c.CheckAny(v))
+	assert.True(c.Check(v))
+	v, _ = Parse("1.7.0")
+	assert.False(c.CheckAny(v))
+	assert.False(c.Check(v))
+}
+
+func TestConstraintInvalid(t *testing.T) {
+	assert := assert.New(t)
+	for _, s := range []string{
+		"",
+		"1.2.3 ||",
+		"abc",
+		">=1.2.3.4",
+		"!=1.2",
+		"<*",
+		"1.2-rc.1",
+	} {
+		_, err := ParseConstraint(s)
+		assert.Error(err, s)
+	}
+}
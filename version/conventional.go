@@ -0,0 +1,98 @@
+package version
+
+import (
+	"regexp"
+	"strings"
+)
+
+var conventionalCommitRe = regexp.MustCompile(`^([a-zA-Z]+)(\([^)]*\))?(!)?: `)
+
+// ConventionalBump classifies a commit message according to the Conventional
+// Commits specification. A breaking change, marked by an exclamation mark after
+// the type or a BREAKING CHANGE footer, requires a major release, a feature a
+// minor release and any other commit a patch release.
+func ConventionalBump(message string) BumpType {
+	if strings.Contains(message, "\nBREAKING CHANGE: ") || strings.Contains(message, "\nBREAKING-CHANGE: ") {
+		return Major
+	}
+	m := conventionalCommitRe.FindStringSubmatch(message)
+	switch {
+	case m == nil:
+		return Patch
+	case m[3] == "!":
+		return Major
+	case strings.EqualFold(m[1], "feat"):
+		return Minor
+	}
+	return Patch
+}
+
+// RecommendBump looks at the commits since the last tag of the repository at
+// path and returns the bump type with the highest impact as determined by
+// ConventionalBump or the classifier passed with WithBumpClassifier. Invalid is
+// returned if HEAD is tagged or no commit requires a release.
+func RecommendBump(path string, opts ...Option) (BumpType, error) {
+	o := newOptions(opts)
+	commits, err := commitsSinceTag(path, o)
+	if err != nil {
+		return Invalid, err
+	}
+	bump := Invalid
+	for _, c := range commits {
+		if b := o.classify(c.Message); b != Invalid && (bump == Invalid || b < bump) {
+			bump = b
+		}
+	}
+	return bump, nil
+}
+
+func (o *options) classify(message string) BumpType {
+	if o.classifier != nil {
+		return o.classifier(message)
+	}
+	return ConventionalBump(message)
+}
+
+// CommitStats counts the commits since the last tag of the repository at path by
+// their Conventional Commits type, e.g. for the header of a changelog. Breaking
+// changes are only counted as breaking regardless of their type, all commits
+// that are neither features nor fixes are counted as other. Like for GitDescribe,
+// the last tag is determined by the tag filters of opts, e.g. WithComponent.
+func CommitStats(path string, opts ...Option) (feat, fix, breaking, other int, err error) {
+	commits, err := commitsSinceTag(path, newOptions(opts))
+	if err != nil {
+		return 0, 0, 0, 0, err
+	}
+	for _, c := range commits {
+		if ConventionalBump(c.Message) == Major {
+			breaking++
+			continue
+		}
+		m := conventionalCommitRe.FindStringSubmatch(c.Message)
+		switch {
+		case m != nil && strings.EqualFold(m[1], "feat"):
+			feat++
+		case m != nil && strings.EqualFold(m[1], "fix"):
+			fix++
+		default:
+			other++
+		}
+	}
+	return feat, fix, breaking, other, nil
+}
+
+// NextVersion returns the version of the repository at path as computed by
+// NewFromRepo bumped by the type returned by RecommendBump, i.e. the version
+// that should be released next. The version is returned unchanged if HEAD is
+// tagged.
+func NextVersion(path string, opts ...Option) (Version, BumpType, error) {
+	v, err := NewFromRepo(path, opts...)
+	if err != nil {
+		return Version{}, Invalid, err
+	}
+	bump, err := RecommendBump(path, opts...)
+	if err != nil {
+		return Version{}, Invalid, err
+	}
+	return v.Bump(bump), bump, nil
+}
@@ -0,0 +1,227 @@
+package version
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConventionalBump(t *testing.T) {
+	assert := assert.New(t)
+	for _, test := range []struct {
+		message string
+		bump    BumpType
+	}{
+		{"fix: handle empty tags", Patch},
+		{"feat: add -plan option", Minor},
+		{"feat(cli): add -plan option", Minor},
+		{"feat!: drop support for go 1.14", Major},
+		{"refactor(version)!: rename Format", Major},
+		{"chore: update dependencies\n\nBREAKING CHANGE: requires go 1.16", Major},
+		{"docs: update readme", Patch},
+		{"Update readme", Patch},
+		{"feature: no conventional type", Patch},
+	} {
+		assert.Equal(test.bump, ConventionalBump(test.message), test.message)
+	}
+}
+
+func TestNextVersion(t *testing.T) {
+	assert := assert.New(t)
+	dir, _ := ioutil.TempDir("", "example")
+	defer os.RemoveAll(dir)
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(err)
+	worktree, err := repo.Worktree()
+	assert.NoError(err)
+	when := time.Now()
+	commit := func(message string) plumbing.Hash {
+		when = when.Add(time.Minute)
+		hash, err := worktree.Commit(message, &git.CommitOptions{Author: &object.Signature{
+			Name:  "John Doe",
+			Email: "john@doe.org",
+			When:  when,
+		}})
+		assert.NoError(err)
+		return hash
+	}
+
+	_, err = repo.CreateTag("v1.2.3", commit("initial commit"), nil)
+	assert.NoError(err)
+
+	test := func(current, next string, bump BumpType) {
+		b, err := RecommendBump(dir)
+		assert.NoError(err)
+		assert.Equal(bump, b)
+		v, b, err := NextVersion(dir)
+		assert.NoError(err)
+		assert.Equal(bump, b)
+		assert.Equal(next, v.String())
+		v, err = NewFromRepo(dir)
+		assert.NoError(err)
+		assert.Equal(current, v.String()[:len(current)])
+	}
+	test("v1.2.3", "v1.2.3", Invalid)
+
+	for _, message := range []string{"docs: update readme", "fix: handle empty tags"} {
+		commit(message)
+	}
+	test("v1.2.4-dev.2", "v1.2.4", Patch)
+
+	commit("feat: add -plan option")
+	test("v1.2.4-dev.3", "v1.3.0", Minor)
+
+	commit("fix!: remove deprecated flags")
+	test("v1.2.4-dev.4", "v2.0.0", Major)
+}
+
+func TestRecommendBumpClassifier(t *testing.T) {
+	assert := assert.New(t)
+	dir, _ := ioutil.TempDir("", "example")
+	defer os.RemoveAll(dir)
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(err)
+	worktree, err := repo.Worktree()
+	assert.NoError(err)
+	when := time.Now()
+	commit := func(message string) plumbing.Hash {
+		when = when.Add(time.Minute)
+		hash, err := worktree.Commit(message, &git.CommitOptions{Author: &object.Signature{
+			Name:  "John Doe",
+			Email: "john@doe.org",
+			When:  when,
+		}})
+		assert.NoError(err)
+		return hash
+	}
+	_, err = repo.CreateTag("v1.2.3", commit("initial commit"), nil)
+	assert.NoError(err)
+
+	jira := WithBumpClassifier(func(message string) BumpType {
+		switch {
+		case strings.HasPrefix(message, "[MAJOR]"):
+			return Major
+		case strings.HasPrefix(message, "[MINOR]"):
+			return Minor
+		case strings.HasPrefix(message, "[PATCH]"):
+			return Patch
+		}
+		return Invalid
+	})
+	test := func(conventional, custom BumpType) {
+		b, err := RecommendBump(dir)
+		assert.NoError(err)
+		assert.Equal(conventional, b)
+		b, err = RecommendBump(dir, jira)
+		assert.NoError(err)
+		assert.Equal(custom, b)
+	}
+
+	commit("PROJ-1 update readme")
+	test(Patch, Invalid)
+	commit("[MINOR] PROJ-2 add option")
+	test(Patch, Minor)
+	commit("feat!: [PATCH] PROJ-3 remove flags")
+	test(Major, Minor)
+	commit("[MAJOR] PROJ-4 new API")
+	test(Major, Major)
+
+	v, b, err := NextVersion(dir, jira)
+	assert.NoError(err)
+	assert.Equal(Major, b)
+	assert.Equal("v2.0.0", v.String())
+}
+
+func TestRecommendBumpComponent(t *testing.T) {
+	assert := assert.New(t)
+	dir, _ := ioutil.TempDir("", "example")
+	defer os.RemoveAll(dir)
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(err)
+	worktree, err := repo.Worktree()
+	assert.NoError(err)
+	when := time.Now()
+	commit := func(message string) plumbing.Hash {
+		when = when.Add(time.Minute)
+		hash, err := worktree.Commit(message, &git.CommitOptions{Author: &object.Signature{
+			Name:  "John Doe",
+			Email: "john@doe.org",
+			When:  when,
+		}})
+		assert.NoError(err)
+		return hash
+	}
+
+	_, err = repo.CreateTag("api/v1.0.0", commit("feat!: initial api"), nil)
+	assert.NoError(err)
+	commit("feat: add endpoint")
+	_, err = repo.CreateTag("web/v1.0.0", commit("fix: web typo"), nil)
+	assert.NoError(err)
+
+	b, err := RecommendBump(dir)
+	assert.NoError(err)
+	assert.Equal(Invalid, b)
+	b, err = RecommendBump(dir, WithComponent("api"))
+	assert.NoError(err)
+	assert.Equal(Minor, b)
+
+	v, b, err := NextVersion(dir, WithComponent("api"))
+	assert.NoError(err)
+	assert.Equal(Minor, b)
+	assert.Equal("api/v1.1.0", v.String())
+
+	feat, fix, breaking, other, err := CommitStats(dir, WithComponent("api"))
+	assert.NoError(err)
+	assert.Equal([]int{1, 1, 0, 0}, []int{feat, fix, breaking, other})
+}
+
+func TestCommitStats(t *testing.T) {
+	assert := assert.New(t)
+	dir, _ := ioutil.TempDir("", "example")
+	defer os.RemoveAll(dir)
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(err)
+	worktree, err := repo.Worktree()
+	assert.NoError(err)
+	when := time.Now()
+	commit := func(message string) plumbing.Hash {
+		when = when.Add(time.Minute)
+		hash, err := worktree.Commit(message, &git.CommitOptions{Author: &object.Signature{
+			Name:  "John Doe",
+			Email: "john@doe.org",
+			When:  when,
+		}})
+		assert.NoError(err)
+		return hash
+	}
+
+	// commits before the tag are not counted
+	commit("feat: initial feature")
+	_, err = repo.CreateTag("v1.2.3", commit("fix: initial fix"), nil)
+	assert.NoError(err)
+	feat, fix, breaking, other, err := CommitStats(dir)
+	assert.NoError(err)
+	assert.Equal([]int{0, 0, 0, 0}, []int{feat, fix, breaking, other})
+
+	for _, message := range []string{
+		"feat: add -plan option",
+		"feat(cli): add -stdin option",
+		"fix: handle empty tags",
+		"feat!: drop the -no-hash option",
+		"fix: remove flags\n\nBREAKING CHANGE: -no-hash is gone",
+		"docs: update readme",
+		"Merge branch 'feature'",
+	} {
+		commit(message)
+	}
+	feat, fix, breaking, other, err = CommitStats(dir)
+	assert.NoError(err)
+	assert.Equal([]int{2, 1, 2, 2}, []int{feat, fix, breaking, other})
+}
@@ -0,0 +1,174 @@
+package version
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var pep440PreReleases = map[string]string{
+	"a":       "a",
+	"alpha":   "a",
+	"b":       "b",
+	"beta":    "b",
+	"c":       "rc",
+	"rc":      "rc",
+	"pre":     "rc",
+	"preview": "rc",
+}
+
+var pep440PreReleaseRe = regexp.MustCompile(`^([a-zA-Z]+)\.?([0-9]*)$`)
+
+// PEP440 returns the version in a format that is compliant to PEP 440 and can be
+// used for Python packages. The pre-release identifiers alpha, beta and rc are
+// mapped to a, b and rc, e.g. 1.2.3-rc.1 becomes 1.2.3rc1. Commits since the last
+// tag are expressed as a development release 1.2.4.dev3, or 1.2.3.dev3 with
+// WithoutAutoIncrement, and the metadata is used as local version label. Since a
+// development release sorts before its release, the pre-release number is
+// incremented in case there are commits on top of a pre-release tag, so that
+// 1.2.3-rc.1.dev.2 becomes 1.2.3rc2.dev2. Pre-release identifiers without a PEP 440
+// equivalent are moved to the local version label.
+func (v Version) PEP440() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.effectivePatch())
+	var local []string
+	if v.preRelease != "" {
+		m := pep440PreReleaseRe.FindStringSubmatch(v.preRelease)
+		if m != nil && pep440PreReleases[strings.ToLower(m[1])] != "" {
+			n, _ := strconv.Atoi(m[2])
+			if v.Commits > 0 {
+				n++
+			}
+			s += fmt.Sprintf("%s%d", pep440PreReleases[strings.ToLower(m[1])], n)
+		} else {
+			local = append(local, v.preRelease)
+		}
+	}
+	if v.Commits > 0 {
+		s += fmt.Sprintf(".dev%d", v.Commits)
+	}
+	if v.Meta != "" {
+		local = append(local, v.Meta)
+	}
+	if len(local) > 0 {
+		s += "+" + strings.ToLower(strings.ReplaceAll(strings.Join(local, "."), "-", "."))
+	}
+	return s
+}
+
+// AssemblyVersion returns the version in the four-part numeric format of .NET
+// assemblies major.minor.patch.revision, where revision is the number of commits
+// since the last tag, e.g. 1.2.3.4 for the fourth commit after the tag 1.2.3.
+// Pre-release and metadata are omitted, since assembly versions are numeric only.
+func (v Version) AssemblyVersion() string {
+	commits := v.Commits
+	if commits < 0 {
+		commits = 0
+	}
+	return fmt.Sprintf("%d.%d.%d.%d", v.Major, v.Minor, v.Patch, commits)
+}
+
+// InformationalVersion returns the full SemVer version without prefix, as it can
+// be used for the AssemblyInformationalVersion attribute of .NET assemblies.
+func (v Version) InformationalVersion() string {
+	return v.WithPrefix("").String()
+}
+
+// Maven returns the version in a format suitable for Maven artifacts. A release
+// is formatted as major.minor.patch. The identifiers of a pre-release are joined
+// with hyphens to form a qualifier, e.g. 1.2.3-rc.1 becomes 1.2.3-rc-1. Commits
+// since the last tag result in a snapshot of the next version, e.g. 1.2.4-SNAPSHOT.
+// Since a snapshot sorts before the version it is based on, the pre-release is
+// incremented like with IncrementPreRelease in case there are commits on top of a
+// pre-release tag, so that 1.2.3-rc.1.dev.2 becomes 1.2.3-rc-2-SNAPSHOT. Branch
+// labels and metadata are omitted.
+func (v Version) Maven() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.effectivePatch())
+	pre := v.preRelease
+	if v.Commits > 0 && pre != "" {
+		if next, err := v.IncrementPreRelease(); err == nil {
+			pre = next.preRelease
+		}
+	}
+	if pre != "" {
+		s += "-" + strings.ReplaceAll(pre, ".", "-")
+	}
+	if v.Commits > 0 {
+		s += "-SNAPSHOT"
+	}
+	return s
+}
+
+// GoModVersion returns the version as Go module version. A tagged commit results
+// in the canonical version of the tag, e.g. v1.2.3-rc.1 without metadata.
+// Otherwise a pseudo-version is formed from the commit time t, usually
+// CommitTime, and the hash abbreviated to 12 characters:
+// * v0.0.0-20240115103000-fcf2c8f12345 if there is no tag
+// * v1.2.4-0.20240115103000-fcf2c8f12345 after the release tag v1.2.3
+// * v1.2.3-rc.1.0.20240115103000-fcf2c8f12345 after the pre-release tag v1.2.3-rc.1
+// The prefix is always v as required by Go modules.
+func (v Version) GoModVersion(t time.Time) string {
+	if v.tag != "" && v.Commits <= 0 {
+		s := fmt.Sprintf("v%d.%d.%d", v.Major, v.Minor, v.Patch)
+		if v.preRelease != "" {
+			s += "-" + v.preRelease
+		}
+		return s
+	}
+	rev := t.UTC().Format(CommitTimeFormat) + "-" + shortHash(v.Hash, 12)
+	switch {
+	case v.tag == "":
+		return fmt.Sprintf("v%d.0.0-%s", v.Major, rev)
+	case v.preRelease != "":
+		return fmt.Sprintf("v%d.%d.%d-%s.0.%s", v.Major, v.Minor, v.Patch, v.preRelease, rev)
+	default:
+		return fmt.Sprintf("v%d.%d.%d-0.%s", v.Major, v.Minor, v.Patch+1, rev)
+	}
+}
+
+var packageIdentifierRe = regexp.MustCompile(`([A-Za-z])\.([0-9])`)
+
+// packageVersion returns the upstream version for Debian and RPM packages, where
+// a tilde introduces a pre-release that sorts before the release. Alphabetic
+// identifiers are joined with the following number, e.g. rc.1 becomes rc1, and
+// commits since the tag are expressed as dev<n>. Since anything but a tilde sorts
+// after the end of a version, 1.2.3~rc1.dev2 sorts in between 1.2.3~rc1 and 1.2.3.
+func (v Version) packageVersion() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.effectivePatch())
+	var pre []string
+	if v.preRelease != "" {
+		pre = append(pre, strings.ReplaceAll(v.preRelease, "-", "."))
+	}
+	if v.Commits > 0 {
+		pre = append(pre, fmt.Sprintf("dev%d", v.Commits))
+	}
+	if len(pre) > 0 {
+		s += "~" + packageIdentifierRe.ReplaceAllString(strings.Join(pre, "."), "$1$2")
+	}
+	return s
+}
+
+// Debian returns the version in the format of Debian packages, e.g. 1.2.3~rc1 for
+// 1.2.3-rc.1 or 1.2.4~dev3 for the third commit after 1.2.3. The metadata is used
+// as Debian revision after a hyphen, e.g. 1.2.4~dev3-fcf2c8fa. Branch labels are
+// omitted.
+func (v Version) Debian() string {
+	s := v.packageVersion()
+	if v.Meta != "" {
+		s += "-" + strings.ReplaceAll(v.Meta, "-", ".")
+	}
+	return s
+}
+
+// RPM returns the version in the format of RPM packages, which is the same as for
+// Debian except for the metadata, which is appended after a plus sign, since RPM
+// versions can't contain hyphens, e.g. 1.2.4~dev3+fcf2c8fa. The tilde requires
+// RPM 4.10 or newer.
+func (v Version) RPM() string {
+	s := v.packageVersion()
+	if v.Meta != "" {
+		s += "+" + strings.ReplaceAll(v.Meta, "-", ".")
+	}
+	return s
+}
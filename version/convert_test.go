@@ -0,0 +1,169 @@
+package version
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPEP440(t *testing.T) {
+	assert := assert.New(t)
+	for _, test := range []struct {
+		v Version
+		s string
+	}{
+		{
+			Version{Major: 1, Minor: 2, Patch: 3},
+			"1.2.3",
+		},
+		{
+			Version{Major: 1, Minor: 2, Patch: 3, preRelease: "rc.1"},
+			"1.2.3rc1",
+		},
+		{
+			Version{Major: 1, Minor: 2, Patch: 2, Commits: 4, Meta: "fcf2c8fa"},
+			"1.2.3.dev4+fcf2c8fa",
+		},
+		{
+			Version{Major: 1, Minor: 2, Patch: 2, Commits: 4, Meta: "fcf2c8fa", noIncrement: true},
+			"1.2.2.dev4+fcf2c8fa",
+		},
+		{
+			Version{Major: 1, Minor: 2, Patch: 3, preRelease: "rc.1", Commits: 2, Meta: "fcf2c8fa"},
+			"1.2.3rc2.dev2+fcf2c8fa",
+		},
+		{
+			Version{Major: 2, Minor: 0, Patch: 0, preRelease: "alpha.01"},
+			"2.0.0a1",
+		},
+		{
+			Version{Major: 2, Minor: 0, Patch: 0, preRelease: "beta"},
+			"2.0.0b0",
+		},
+		{
+			Version{Major: 3, Minor: 2, Patch: 1, preRelease: "liftoff.alpha.1", Meta: "Special-Build"},
+			"3.2.1+liftoff.alpha.1.special.build",
+		},
+	} {
+		assert.Equal(test.s, test.v.PEP440())
+	}
+}
+
+func TestAssemblyVersion(t *testing.T) {
+	assert := assert.New(t)
+	for _, test := range []struct {
+		v        Version
+		assembly string
+		informal string
+	}{
+		{
+			Version{Major: 1, Minor: 2, Patch: 3},
+			"1.2.3.0",
+			"1.2.3",
+		},
+		{
+			Version{Prefix: "v", Major: 1, Minor: 2, Patch: 3, Commits: 4, Meta: "fcf2c8fa"},
+			"1.2.3.4",
+			"1.2.4-dev.4+fcf2c8fa",
+		},
+		{
+			Version{Major: 2, Minor: 0, Patch: 0, preRelease: "rc.1", Commits: 2, Meta: "fcf2c8fa"},
+			"2.0.0.2",
+			"2.0.0-rc.1.dev.2+fcf2c8fa",
+		},
+	} {
+		assert.Equal(test.assembly, test.v.AssemblyVersion())
+		assert.Equal(test.informal, test.v.InformationalVersion())
+	}
+}
+
+func TestMaven(t *testing.T) {
+	assert := assert.New(t)
+	for _, test := range []struct {
+		v Version
+		s string
+	}{
+		{
+			Version{Prefix: "v", Major: 1, Minor: 2, Patch: 3, Meta: "special"},
+			"1.2.3",
+		},
+		{
+			Version{Major: 1, Minor: 2, Patch: 3, Commits: 4, Meta: "fcf2c8fa"},
+			"1.2.4-SNAPSHOT",
+		},
+		{
+			Version{Major: 1, Minor: 2, Patch: 3, preRelease: "rc.1"},
+			"1.2.3-rc-1",
+		},
+		{
+			Version{Major: 1, Minor: 2, Patch: 3, preRelease: "rc.1", Commits: 2, Meta: "fcf2c8fa"},
+			"1.2.3-rc-2-SNAPSHOT",
+		},
+		{
+			Version{Major: 2, Minor: 0, Patch: 0, preRelease: "beta", Commits: 1, branch: "feature-x"},
+			"2.0.0-beta-1-SNAPSHOT",
+		},
+	} {
+		assert.Equal(test.s, test.v.Maven())
+	}
+}
+
+func TestGoModVersion(t *testing.T) {
+	assert := assert.New(t)
+	hash := "fcf2c8f12345a8b6e4e0c9e1d0e3b5a7c6d4f2e1"
+	commitTime := time.Date(2024, 1, 15, 11, 30, 0, 0, time.FixedZone("CET", 3600))
+	for _, test := range []struct {
+		ref RepoHead
+		s   string
+	}{
+		{RepoHead{CommitsSinceTag: 3, Hash: hash}, "v0.0.0-20240115103000-fcf2c8f12345"},
+		{RepoHead{LastTag: "v1.2.3", CommitsSinceTag: 3, Hash: hash}, "v1.2.4-0.20240115103000-fcf2c8f12345"},
+		{RepoHead{LastTag: "1.2.3", CommitsSinceTag: 3, Hash: hash}, "v1.2.4-0.20240115103000-fcf2c8f12345"},
+		{RepoHead{LastTag: "v1.2.3-rc.1", CommitsSinceTag: 3, Hash: hash}, "v1.2.3-rc.1.0.20240115103000-fcf2c8f12345"},
+		{RepoHead{LastTag: "v1.2.3", Hash: hash}, "v1.2.3"},
+		{RepoHead{LastTag: "v1.2.3-rc.1+build.5", Hash: hash}, "v1.2.3-rc.1"},
+	} {
+		v, err := NewFromHead(&test.ref)
+		assert.NoError(err)
+		assert.Equal(test.s, v.GoModVersion(commitTime))
+	}
+}
+
+func TestDebianAndRPM(t *testing.T) {
+	assert := assert.New(t)
+	for _, test := range []struct {
+		v      Version
+		debian string
+		rpm    string
+	}{
+		{
+			Version{Prefix: "v", Major: 1, Minor: 2, Patch: 3},
+			"1.2.3",
+			"1.2.3",
+		},
+		{
+			Version{Major: 1, Minor: 2, Patch: 3, preRelease: "rc.1"},
+			"1.2.3~rc1",
+			"1.2.3~rc1",
+		},
+		{
+			Version{Major: 1, Minor: 2, Patch: 3, Commits: 3, Meta: "fcf2c8fa"},
+			"1.2.4~dev3-fcf2c8fa",
+			"1.2.4~dev3+fcf2c8fa",
+		},
+		{
+			Version{Major: 1, Minor: 2, Patch: 3, preRelease: "rc.1", Commits: 2, branch: "feature-x"},
+			"1.2.3~rc1.dev2",
+			"1.2.3~rc1.dev2",
+		},
+		{
+			Version{Major: 2, Minor: 0, Patch: 0, preRelease: "alpha.beta-2.3", Meta: "build-5"},
+			"2.0.0~alpha.beta2.3-build.5",
+			"2.0.0~alpha.beta2.3+build.5",
+		},
+	} {
+		assert.Equal(test.debian, test.v.Debian())
+		assert.Equal(test.rpm, test.v.RPM())
+	}
+}
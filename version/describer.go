@@ -0,0 +1,161 @@
+package version
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Describer obtains the describe information of the repository at path.
+type Describer interface {
+	Describe(path string, opts ...Option) (*RepoHead, error)
+}
+
+// GoGitDescriber is the default Describer, that reads the repository with go-git
+// like GitDescribe.
+type GoGitDescriber struct{}
+
+// Describe implements Describer.
+func (GoGitDescriber) Describe(path string, opts ...Option) (*RepoHead, error) {
+	return GitDescribe(path, opts...)
+}
+
+// GitBinaryDescriber is a Describer that runs git describe of the git binary
+// found in PATH, e.g. for repositories where go-git diverges from git. Like for
+// GitDescribe, the tags of the described commit are filtered by the options and
+// the one with the highest precedence is used. Commits without a tag that passes
+// the filters are skipped by excluding their tags from git describe. WithAllTags
+// and WithCache are not supported.
+type GitBinaryDescriber struct{}
+
+// Describe implements Describer.
+func (d GitBinaryDescriber) Describe(path string, opts ...Option) (*RepoHead, error) {
+	o := newOptions(opts)
+	switch {
+	case o.allTags:
+		return nil, errors.New("WithAllTags is not supported by GitBinaryDescriber")
+	case o.cache:
+		return nil, errors.New("WithCache is not supported by GitBinaryDescriber")
+	}
+	repo := []string{"-C", path}
+	if o.workTree != "" {
+		repo = []string{"--git-dir", path, "--work-tree", o.workTree}
+	}
+
+	head := &RepoHead{}
+	excluded := make(map[string]bool)
+	for {
+		args := []string{"describe", "--tags", "--long", "--always", "--abbrev=40"}
+		if o.component != "" {
+			args = append(args, "--match", o.component+"/*")
+		}
+		for name := range excluded {
+			args = append(args, "--exclude", name)
+		}
+		out, err := d.git(repo, args...)
+		if err != nil {
+			return nil, err
+		}
+		m := describeRe.FindStringSubmatch(out)
+		if m == nil {
+			// --always prints the hash if there is no tag
+			head.Hash = out
+			break
+		}
+		if excluded[m[1]] {
+			return nil, fmt.Errorf("git describe returned the excluded tag %s", m[1])
+		}
+		commit, err := d.git(repo, "rev-parse", "refs/tags/"+m[1]+"^{commit}")
+		if err != nil {
+			return nil, err
+		}
+		list, err := d.git(repo, "tag", "--points-at", commit)
+		if err != nil {
+			return nil, err
+		}
+		names := strings.Fields(list)
+		if filtered := o.filterTags(names); len(filtered) > 0 {
+			sort.Strings(filtered)
+			head.Tags = filtered
+			head.LastTag = highestTag(filtered)
+			head.Hash = m[3]
+			if head.CommitsSinceTag, err = strconv.Atoi(m[2]); err != nil {
+				return nil, fmt.Errorf("failed to parse number of commits: %w", err)
+			}
+			break
+		}
+		for _, name := range names {
+			excluded[name] = true
+		}
+	}
+
+	if head.LastTag != "" {
+		date, err := d.git(repo, "for-each-ref", "--format=%(creatordate:iso-strict)", "refs/tags/"+head.LastTag)
+		if err != nil {
+			return nil, err
+		}
+		if head.TagTime, err = time.Parse(time.RFC3339, date); err != nil {
+			return nil, fmt.Errorf("failed to parse tag time: %w", err)
+		}
+	}
+	if o.totalCommits || head.LastTag == "" {
+		count, err := d.git(repo, "rev-list", "--count", "HEAD")
+		if err != nil {
+			return nil, err
+		}
+		if head.TotalCommits, err = strconv.Atoi(count); err != nil {
+			return nil, fmt.Errorf("failed to parse number of commits: %w", err)
+		}
+	}
+	if head.LastTag == "" {
+		head.CommitsSinceTag = head.TotalCommits
+	}
+	dateFormat := "--format=%cI"
+	if o.authorDate {
+		dateFormat = "--format=%aI"
+	}
+	date, err := d.git(repo, "log", "-1", dateFormat, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	if head.CommitTime, err = time.Parse(time.RFC3339, date); err != nil {
+		return nil, fmt.Errorf("failed to parse commit time: %w", err)
+	}
+	// symbolic-ref fails for a detached HEAD, which has no branch
+	if branch, err := d.git(repo, "symbolic-ref", "--short", "-q", "HEAD"); err == nil {
+		head.Branch = branch
+	}
+	return head, nil
+}
+
+// git runs the git binary with args in the repository selected by the global
+// options repo, e.g. -C <path>, and returns its trimmed output.
+func (GitBinaryDescriber) git(repo []string, args ...string) (string, error) {
+	bin, err := exec.LookPath("git")
+	if err != nil {
+		return "", fmt.Errorf("failed to find the git binary in PATH: %w", err)
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command(bin, append(append([]string{}, repo...), args...)...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to run git %s: %s: %w", args[0], strings.TrimSpace(stderr.String()), err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// NewFromDescriber is like NewFromRepo but obtains the describe information of
+// the repository at path from d.
+func NewFromDescriber(d Describer, path string, opts ...Option) (Version, error) {
+	head, err := d.Describe(path, opts...)
+	if err != nil {
+		return Version{}, err
+	}
+	return NewFromHead(head, opts...)
+}
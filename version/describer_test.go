@@ -0,0 +1,89 @@
+package version
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGitBinaryDescriber(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git binary not found")
+	}
+	assert := assert.New(t)
+	dir, _ := ioutil.TempDir("", "example")
+	defer os.RemoveAll(dir)
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(err)
+
+	compare := func(step int, opts ...Option) {
+		opts = append(opts, WithTotalCommits())
+		expected, err := GoGitDescriber{}.Describe(dir, opts...)
+		assert.NoError(err)
+		actual, err := GitBinaryDescriber{}.Describe(dir, opts...)
+		assert.NoError(err)
+		assert.Equal(expected.LastTag, actual.LastTag, "step %d", step)
+		assert.Equal(expected.Tags, actual.Tags, "step %d", step)
+		assert.Equal(expected.CommitsSinceTag, actual.CommitsSinceTag, "step %d", step)
+		assert.Equal(expected.TotalCommits, actual.TotalCommits, "step %d", step)
+		assert.Equal(expected.Hash, actual.Hash, "step %d", step)
+		assert.Equal(expected.Branch, actual.Branch, "step %d", step)
+		assert.True(expected.CommitTime.Equal(actual.CommitTime), "step %d", step)
+		assert.True(expected.TagTime.Equal(actual.TagTime), "step %d", step)
+
+		v1, err1 := NewFromDescriber(GoGitDescriber{}, dir, opts...)
+		v2, err2 := NewFromDescriber(GitBinaryDescriber{}, dir, opts...)
+		assert.Equal(err1, err2, "step %d", step)
+		assert.Equal(v1.String(), v2.String(), "step %d", step)
+	}
+
+	worktree, err := repo.Worktree()
+	assert.NoError(err)
+	// git describe prefers the most recent of multiple tags on a commit, whereas
+	// the one with the highest precedence is used
+	for i, tags := range [][]string{nil, {"v1.0.0", "v0.9.0"}, nil, {"v1.1.0-rc.1"}, {"nightly"}, nil} {
+		head, err := worktree.Commit("commit", &git.CommitOptions{Author: &object.Signature{
+			Name:  "John Doe",
+			Email: "john@doe.org",
+			When:  time.Unix(int64(i), 0),
+		}})
+		assert.NoError(err)
+		for j, tag := range tags {
+			_, err = repo.CreateTag(tag, head, &git.CreateTagOptions{
+				Tagger:  &object.Signature{Name: "John Doe", Email: "john@doe.org", When: time.Unix(int64(i*10+j), 0)},
+				Message: "Release " + tag,
+			})
+			assert.NoError(err)
+		}
+		compare(i)
+	}
+
+	for i, opts := range [][]Option{
+		{WithSkipNonSemverTags()},
+		{WithSkipNonSemverTags(), WithStableTagsOnly()},
+		{WithMatchRegex(regexp.MustCompile(`^v1\.0`))},
+		{WithIgnoreTags("nightly", "1.1.0-rc.1")},
+		{WithIgnoreTags("nightly"), WithStrictTags()},
+		{WithComponent("api")},
+	} {
+		compare(10+i, opts...)
+	}
+
+	for _, opt := range []Option{WithAllTags(), WithCache()} {
+		_, err = GitBinaryDescriber{}.Describe(dir, opt)
+		assert.Error(err)
+	}
+
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv("PATH", "")
+	_, err = NewFromDescriber(GitBinaryDescriber{}, dir)
+	assert.Error(err)
+	assert.Contains(err.Error(), "failed to find the git binary")
+}
@@ -0,0 +1,47 @@
+package version
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Diff describes the changes from v to other component by component, e.g. major+1
+// for 1.2.3 to 2.2.3, minor+2 patch-reset for 1.2.3 to 1.4.0 or prerelease
+// rc.1->rc.2 for 1.2.3-rc.1 to 1.2.3-rc.2. Components that are set to 0 after a
+// higher component changed are reported as reset. A removed or added pre-release
+// is shown as none. Prefix and metadata are ignored and unchanged is returned if
+// there is no difference.
+func (v Version) Diff(other Version) string {
+	var changes []string
+	changed := false
+	for _, c := range []struct {
+		name     string
+		from, to int
+	}{
+		{"major", v.Major, other.Major},
+		{"minor", v.Minor, other.Minor},
+		{"patch", v.effectivePatch(), other.effectivePatch()},
+	} {
+		switch {
+		case c.from == c.to:
+		case changed && c.to == 0:
+			changes = append(changes, c.name+"-reset")
+		default:
+			changes = append(changes, fmt.Sprintf("%s%+d", c.name, c.to-c.from))
+			changed = true
+		}
+	}
+	if from, to := v.PreRelease(), other.PreRelease(); from != to {
+		if from == "" {
+			from = "none"
+		}
+		if to == "" {
+			to = "none"
+		}
+		changes = append(changes, fmt.Sprintf("prerelease %s->%s", from, to))
+	}
+	if len(changes) == 0 {
+		return "unchanged"
+	}
+	return strings.Join(changes, " ")
+}
@@ -0,0 +1,31 @@
+package version
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	assert := assert.New(t)
+	for _, test := range []struct {
+		from, to string
+		diff     string
+	}{
+		{"1.0.0", "2.0.0", "major+1"},
+		{"1.2.3", "2.0.0", "major+1 minor-reset patch-reset"},
+		{"1.2.3", "1.4.0", "minor+2 patch-reset"},
+		{"1.2.3", "1.2.4", "patch+1"},
+		{"1.2.3", "1.2.1", "patch-2"},
+		{"1.2.3-rc.1", "1.2.3-rc.2", "prerelease rc.1->rc.2"},
+		{"1.2.3-rc.2", "1.2.3", "prerelease rc.2->none"},
+		{"1.2.3", "1.3.0-beta", "minor+1 patch-reset prerelease none->beta"},
+		{"v1.2.3+build.1", "1.2.3+build.2", "unchanged"},
+	} {
+		from, err := Parse(test.from)
+		assert.NoError(err)
+		to, err := Parse(test.to)
+		assert.NoError(err)
+		assert.Equal(test.diff, from.Diff(to), "%s %s", test.from, test.to)
+	}
+}
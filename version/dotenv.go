@@ -0,0 +1,46 @@
+package version
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var shellSafeRe = regexp.MustCompile(`^[0-9A-Za-z.+_/:-]*$`)
+
+// shellQuote quotes s with single quotes, unless it only consists of characters
+// that have no special meaning to a shell.
+func shellQuote(s string) string {
+	if shellSafeRe.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Dotenv returns the version and its components as KEY=value lines, that can be
+// sourced by a shell or used as a dotenv report in GitLab CI. The following keys
+// are written: VERSION, VERSION_MAJOR, VERSION_MINOR, VERSION_PATCH and
+// VERSION_PRERELEASE.
+func (v Version) Dotenv() string {
+	var b strings.Builder
+	for _, kv := range [][2]string{
+		{"VERSION", v.String()},
+		{"VERSION_MAJOR", strconv.Itoa(v.Major)},
+		{"VERSION_MINOR", strconv.Itoa(v.Minor)},
+		{"VERSION_PATCH", strconv.Itoa(v.effectivePatch())},
+		{"VERSION_PRERELEASE", v.PreRelease()},
+	} {
+		fmt.Fprintf(&b, "%s=%s\n", kv[0], shellQuote(kv[1]))
+	}
+	return b.String()
+}
+
+// WriteDotenv writes the output of Dotenv to the file at path.
+func (v Version) WriteDotenv(path string) error {
+	if err := ioutil.WriteFile(path, []byte(v.Dotenv()), 0644); err != nil {
+		return fmt.Errorf("failed to write dotenv file: %w", err)
+	}
+	return nil
+}
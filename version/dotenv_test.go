@@ -0,0 +1,41 @@
+package version
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteDotenv(t *testing.T) {
+	assert := assert.New(t)
+	dir, _ := ioutil.TempDir("", "example")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "build.env")
+
+	v := Version{Prefix: "v", Major: 1, Minor: 2, Patch: 3, preRelease: "rc.1", Commits: 4, Meta: "fcf2c8fa"}
+	assert.NoError(v.WriteDotenv(path))
+	b, err := ioutil.ReadFile(path)
+	assert.NoError(err)
+	assert.Equal(`VERSION=v1.2.3-rc.1.dev.4+fcf2c8fa
+VERSION_MAJOR=1
+VERSION_MINOR=2
+VERSION_PATCH=3
+VERSION_PRERELEASE=rc.1.dev.4
+`, string(b))
+
+	v = Version{Prefix: "it's ", Major: 2}
+	assert.NoError(v.WriteDotenv(path))
+	b, err = ioutil.ReadFile(path)
+	assert.NoError(err)
+	assert.Equal(`VERSION='it'\''s 2.0.0'
+VERSION_MAJOR=2
+VERSION_MINOR=0
+VERSION_PATCH=0
+VERSION_PRERELEASE=
+`, string(b))
+
+	assert.Error(v.WriteDotenv(filepath.Join(dir, "missing", "build.env")))
+}
@@ -0,0 +1,156 @@
+package version
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MarshalYAML implements yaml.Marshaler. The version is serialized as scalar
+// string in the full format.
+func (v Version) MarshalYAML() (interface{}, error) {
+	return v.String(), nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler. The version is expected to be a
+// scalar string, that is parsed with Parse.
+func (v *Version) UnmarshalYAML(value *yaml.Node) error {
+	var s string
+	if err := value.Decode(&s); err != nil {
+		return err
+	}
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*v = parsed
+	return nil
+}
+
+// Value implements driver.Valuer. The version is stored as string in the full
+// format.
+func (v Version) Value() (driver.Value, error) {
+	return v.String(), nil
+}
+
+// Scan implements sql.Scanner. String and []byte columns are parsed with Parse,
+// NULL results in the zero Version.
+func (v *Version) Scan(src interface{}) error {
+	var s string
+	switch src := src.(type) {
+	case nil:
+		*v = Version{}
+		return nil
+	case string:
+		s = src
+	case []byte:
+		s = string(src)
+	default:
+		return fmt.Errorf("failed to scan version: unsupported type %T", src)
+	}
+	parsed, err := Parse(s)
+	if err != nil {
+		return fmt.Errorf("failed to scan version: %w", err)
+	}
+	*v = parsed
+	return nil
+}
+
+// binaryVersion is the first byte of the binary encoding of a Version. Every
+// version appends one string to its predecessor: version 2 the dev separator and
+// version 3 the tag time. Older versions are still accepted by UnmarshalBinary.
+const binaryVersion = 3
+
+// MarshalBinary implements encoding.BinaryMarshaler. All fields including the
+// commit and tag time are encoded compactly as varints and length-prefixed strings.
+func (v Version) MarshalBinary() ([]byte, error) {
+	commitTime, err := v.CommitTime.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal commit time: %w", err)
+	}
+	tagTime, err := v.TagTime.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal tag time: %w", err)
+	}
+	var flags int64
+	if v.noIncrement {
+		flags |= 1
+	}
+	if v.Dirty {
+		flags |= 2
+	}
+	b := []byte{binaryVersion}
+	for _, n := range []int64{int64(v.Major), int64(v.Minor), int64(v.Patch), int64(v.Commits), int64(v.releaseCandidate), flags} {
+		b = appendVarint(b, n)
+	}
+	for _, s := range []string{v.Prefix, v.preRelease, v.Meta, v.Hash, v.tag, v.branch, string(commitTime), v.devSeparator, string(tagTime)} {
+		b = appendVarint(b, int64(len(s)))
+		b = append(b, s...)
+	}
+	return b, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler for data written by
+// MarshalBinary.
+func (v *Version) UnmarshalBinary(data []byte) error {
+	r := bytes.NewReader(data)
+	encoding, err := r.ReadByte()
+	if err != nil || encoding < 1 || encoding > binaryVersion {
+		return errors.New("failed to unmarshal version: unsupported encoding")
+	}
+	var ns [6]int64
+	for i := range ns {
+		n, err := binary.ReadVarint(r)
+		if err != nil {
+			return fmt.Errorf("failed to unmarshal version: %w", err)
+		}
+		ns[i] = n
+	}
+	ss := make([]string, 6+encoding)
+	for i := range ss {
+		n, err := binary.ReadVarint(r)
+		if err != nil {
+			return fmt.Errorf("failed to unmarshal version: %w", err)
+		}
+		if n < 0 || n > int64(r.Len()) {
+			return errors.New("failed to unmarshal version: invalid string length")
+		}
+		b := make([]byte, n)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return fmt.Errorf("failed to unmarshal version: %w", err)
+		}
+		ss[i] = string(b)
+	}
+	if r.Len() > 0 {
+		return errors.New("failed to unmarshal version: trailing data")
+	}
+	var parsed Version
+	if err := parsed.CommitTime.UnmarshalBinary([]byte(ss[6])); err != nil {
+		return fmt.Errorf("failed to unmarshal commit time: %w", err)
+	}
+	parsed.Major, parsed.Minor, parsed.Patch = int(ns[0]), int(ns[1]), int(ns[2])
+	parsed.Commits, parsed.releaseCandidate, parsed.noIncrement = int(ns[3]), int(ns[4]), ns[5]&1 != 0
+	parsed.Dirty = ns[5]&2 != 0
+	parsed.Prefix, parsed.preRelease, parsed.Meta, parsed.Hash = ss[0], ss[1], ss[2], ss[3]
+	parsed.tag, parsed.branch = ss[4], ss[5]
+	if encoding > 1 {
+		parsed.devSeparator = ss[7]
+	}
+	if encoding > 2 {
+		if err := parsed.TagTime.UnmarshalBinary([]byte(ss[8])); err != nil {
+			return fmt.Errorf("failed to unmarshal tag time: %w", err)
+		}
+	}
+	*v = parsed
+	return nil
+}
+
+func appendVarint(b []byte, n int64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return append(b, buf[:binary.PutVarint(buf[:], n)]...)
+}
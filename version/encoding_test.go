@@ -0,0 +1,116 @@
+package version
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestYAML(t *testing.T) {
+	assert := assert.New(t)
+	type manifest struct {
+		Name    string  `yaml:"name"`
+		Version Version `yaml:"version"`
+	}
+
+	m := manifest{
+		Name:    "git-semver",
+		Version: Version{Prefix: "v", Major: 1, Minor: 2, Patch: 3, Commits: 4, Meta: "fcf2c8fa"},
+	}
+	b, err := yaml.Marshal(m)
+	assert.NoError(err)
+	assert.Equal("name: git-semver\nversion: v1.2.4-dev.4+fcf2c8fa\n", string(b))
+
+	var decoded manifest
+	assert.NoError(yaml.Unmarshal(b, &decoded))
+	assert.Equal("git-semver", decoded.Name)
+	assert.Equal(m.Version.String(), decoded.Version.String())
+	assert.Equal(0, decoded.Version.Compare(m.Version))
+
+	assert.Error(yaml.Unmarshal([]byte("version: 1.2\n"), &decoded))
+	assert.Error(yaml.Unmarshal([]byte("version: [1, 2, 3]\n"), &decoded))
+}
+
+func TestSQL(t *testing.T) {
+	assert := assert.New(t)
+	for _, v := range []Version{
+		{Prefix: "v", Major: 1, Minor: 2, Patch: 3},
+		{Major: 1, Minor: 2, Patch: 3, Commits: 4, Meta: "fcf2c8fa"},
+	} {
+		value, err := v.Value()
+		assert.NoError(err)
+		assert.Equal(v.String(), value)
+
+		var scanned Version
+		assert.NoError(scanned.Scan(value))
+		assert.Equal(v.String(), scanned.String())
+		assert.NoError(scanned.Scan([]byte(value.(string))))
+		assert.Equal(v.String(), scanned.String())
+	}
+
+	scanned := Version{Major: 1}
+	assert.NoError(scanned.Scan(nil))
+	assert.True(scanned.IsZero())
+
+	assert.Error(scanned.Scan("1.2"))
+	assert.Error(scanned.Scan(int64(1)))
+}
+
+func TestBinary(t *testing.T) {
+	assert := assert.New(t)
+	for _, v := range []Version{
+		{},
+		{
+			Prefix:           "v",
+			Major:            1,
+			Minor:            2,
+			Patch:            3,
+			preRelease:       "rc.1",
+			Commits:          4,
+			Meta:             "fcf2c8fa",
+			Hash:             "fcf2c8fa52f6e1c3",
+			CommitTime:       time.Date(2020, 12, 8, 10, 30, 0, 0, time.FixedZone("CET", 3600)),
+			releaseCandidate: 2,
+			tag:              "v1.2.3-rc.1",
+			branch:           "feature-x",
+			noIncrement:      true,
+			devSeparator:     "-",
+			TagTime:          time.Date(2020, 12, 1, 9, 0, 0, 0, time.UTC),
+		},
+		{Major: 1, Commits: 2, Hash: "fcf2c8fa", Dirty: true},
+	} {
+		b, err := v.MarshalBinary()
+		assert.NoError(err)
+		var decoded Version
+		assert.NoError(decoded.UnmarshalBinary(b))
+		assert.True(v.CommitTime.Equal(decoded.CommitTime))
+		assert.True(v.TagTime.Equal(decoded.TagTime))
+		decoded.CommitTime, decoded.TagTime = v.CommitTime, v.TagTime
+		assert.Equal(v, decoded)
+
+		assert.Error(decoded.UnmarshalBinary(b[:len(b)-1]))
+		assert.Error(decoded.UnmarshalBinary(append(b, 0)))
+	}
+	var decoded Version
+	assert.Error(decoded.UnmarshalBinary(nil))
+	assert.Error(decoded.UnmarshalBinary([]byte{2}))
+	assert.Error(decoded.UnmarshalBinary([]byte{4}))
+
+	// version 1 has neither the dev separator nor the tag time, which are the
+	// last strings
+	v := Version{Major: 1, Minor: 2, Patch: 3, preRelease: "rc.1", Commits: 2}
+	b, err := v.MarshalBinary()
+	assert.NoError(err)
+	tagTime, err := v.TagTime.MarshalBinary()
+	assert.NoError(err)
+	b = b[:len(b)-len(tagTime)-1]
+	b[0] = 2
+	assert.NoError(decoded.UnmarshalBinary(b))
+	assert.Equal("1.2.3-rc.1.dev.2", decoded.String())
+	b[0] = 1
+	assert.NoError(decoded.UnmarshalBinary(b[:len(b)-1]))
+	assert.Equal("1.2.3-rc.1.dev.2", decoded.String())
+	assert.True(decoded.TagTime.IsZero())
+}
@@ -0,0 +1,139 @@
+package version
+
+import (
+	"io"
+	"strconv"
+	"sync"
+)
+
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		b := make(buffer, 0, 64)
+		return &b
+	},
+}
+
+// Formatter formats versions according to a format string that is parsed and
+// validated only once. It should be preferred over Version.Format when many
+// versions are formatted with the same format, e.g. when listing all tags. A
+// Formatter is safe for concurrent use.
+type Formatter struct {
+	tokens   []formatToken
+	decorate func(verb byte, s string) string
+}
+
+// NewFormatter parses the format string as described for Version.Format.
+func NewFormatter(format string) (*Formatter, error) {
+	tokens, err := parseFormat(format)
+	if err != nil {
+		return nil, err
+	}
+	return &Formatter{tokens: tokens}, nil
+}
+
+// WithMetaSeparator returns a copy of the formatter that uses sep instead of the
+// separator given in the format string in front of the metadata, e.g. to replace
+// the plus sign for systems that don't support it. Note that the result is no
+// valid SemVer anymore.
+func (f *Formatter) WithMetaSeparator(sep string) *Formatter {
+	tokens := make([]formatToken, len(f.tokens))
+	for i, t := range f.tokens {
+		if t.verb == 'm' {
+			t.sep = sep
+		}
+		tokens[i] = t
+	}
+	return &Formatter{tokens: tokens, decorate: f.decorate}
+}
+
+// WithDecorator returns a copy of the formatter that passes every non-empty
+// component to decorate together with its format verb, e.g. to highlight the
+// components with ANSI colors. The separators and the prefix are not decorated.
+func (f *Formatter) WithDecorator(decorate func(verb byte, s string) string) *Formatter {
+	return &Formatter{tokens: f.tokens, decorate: decorate}
+}
+
+// Format returns the string representation of v.
+func (f *Formatter) Format(v Version) (string, error) {
+	buf := bufferPool.Get().(*buffer)
+	defer putBuffer(buf)
+	if err := f.appendTokens(buf, v); err != nil {
+		return "", err
+	}
+	return v.Prefix + string(*buf), nil
+}
+
+// FormatInto writes the string representation of v to w without allocating an
+// intermediate string, e.g. to stream many versions to a bufio.Writer.
+func (f *Formatter) FormatInto(w io.Writer, v Version) error {
+	buf := bufferPool.Get().(*buffer)
+	defer putBuffer(buf)
+	if err := f.appendTokens(buf, v); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, v.Prefix); err != nil {
+		return err
+	}
+	_, err := w.Write(*buf)
+	return err
+}
+
+func putBuffer(buf *buffer) {
+	*buf = (*buf)[:0]
+	bufferPool.Put(buf)
+}
+
+// appendTokens appends the components of v to buf without the prefix, since
+// separators are only added in between components.
+func (f *Formatter) appendTokens(buf *buffer, v Version) error {
+	for _, t := range f.tokens {
+		switch t.verb {
+		case 'x':
+			f.appendInt(buf, t, v.Major)
+		case 'y':
+			f.appendInt(buf, t, v.Minor)
+		case 'z':
+			f.appendInt(buf, t, v.effectivePatch())
+		case 'p':
+			f.appendString(buf, t, v.PreRelease())
+		case 'P':
+			f.appendString(buf, t, v.PreReleaseRaw())
+		case 'r':
+			releaseCandidate, err := v.ReleaseCandidate()
+			if err != nil {
+				return err
+			}
+			f.appendString(buf, t, releaseCandidate)
+		case 'm':
+			f.appendString(buf, t, v.Meta)
+		case 'H':
+			f.appendString(buf, t, v.Hash)
+		case 'd':
+			if !v.CommitTime.IsZero() {
+				f.appendString(buf, t, v.CommitTime.UTC().Format(CommitTimeFormat))
+			}
+		case 'n':
+			commits := v.Commits
+			if commits < 0 {
+				commits = 0
+			}
+			f.appendInt(buf, t, commits)
+		}
+	}
+	return nil
+}
+
+func (f *Formatter) appendInt(buf *buffer, t formatToken, i int) {
+	if f.decorate != nil {
+		buf.AppendString(f.decorate(t.verb, strconv.Itoa(i)), t.sep)
+		return
+	}
+	buf.AppendInt(i, t.sep)
+}
+
+func (f *Formatter) appendString(buf *buffer, t formatToken, s string) {
+	if f.decorate != nil && s != "" {
+		s = f.decorate(t.verb, s)
+	}
+	buf.AppendString(s, t.sep)
+}
@@ -0,0 +1,126 @@
+package version
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatter(t *testing.T) {
+	assert := assert.New(t)
+	_, err := NewFormatter("x.y.z-q")
+	assert.Error(err)
+
+	f, err := NewFormatter(FullFormat)
+	assert.NoError(err)
+	for _, v := range []Version{
+		{Prefix: "v", Major: 1, Minor: 2, Patch: 3},
+		{Major: 1, Minor: 2, Patch: 3, preRelease: "rc.1", Commits: 2, Meta: "fcf2c8fa"},
+		{Prefix: "v", Major: 0, Minor: 0, Patch: 1, Commits: 10, Meta: "fcf2c8fa"},
+	} {
+		expected, err := v.Format(FullFormat)
+		assert.NoError(err)
+		s, err := f.Format(v)
+		assert.NoError(err)
+		assert.Equal(expected, s)
+	}
+
+	f, err = NewFormatter("x.y.z-r")
+	assert.NoError(err)
+	_, err = f.Format(Version{preRelease: "alpha"})
+	assert.Error(err)
+}
+
+var benchmarkVersion = Version{Prefix: "v", Major: 1, Minor: 2, Patch: 3, preRelease: "rc.1", Meta: "fcf2c8fa"}
+
+func BenchmarkVersionFormat(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = benchmarkVersion.Format(FullFormat)
+	}
+}
+
+func BenchmarkFormatterFormat(b *testing.B) {
+	f, err := NewFormatter(FullFormat)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = f.Format(benchmarkVersion)
+	}
+}
+
+func TestFormatterWithMetaSeparator(t *testing.T) {
+	assert := assert.New(t)
+	f, err := NewFormatter(FullFormat)
+	assert.NoError(err)
+	v := Version{Major: 1, Minor: 2, Patch: 3, Commits: 2, Meta: "fcf2c8fa"}
+	for _, test := range []struct {
+		sep string
+		s   string
+	}{
+		{".", "1.2.4-dev.2.fcf2c8fa"},
+		{"_", "1.2.4-dev.2_fcf2c8fa"},
+		{"-build-", "1.2.4-dev.2-build-fcf2c8fa"},
+	} {
+		s, err := f.WithMetaSeparator(test.sep).Format(v)
+		assert.NoError(err)
+		assert.Equal(test.s, s)
+	}
+	s, err := f.Format(v)
+	assert.NoError(err)
+	assert.Equal("1.2.4-dev.2+fcf2c8fa", s)
+}
+
+func TestFormatInto(t *testing.T) {
+	assert := assert.New(t)
+	for _, test := range []struct {
+		v      Version
+		format string
+	}{
+		{Version{Prefix: "v", Major: 1, Minor: 2, Patch: 3}, FullFormat},
+		{Version{Major: 1, Minor: 2, Patch: 3, preRelease: "rc.1", Commits: 2, Meta: "fcf2c8fa"}, FullFormat},
+		{Version{Prefix: "v", Major: 1, Minor: 2, Patch: 3, Commits: 2, Meta: "fcf2c8fa"}, "x_y_z"},
+		{Version{Major: 1, Minor: 2, Patch: 3, preRelease: "rc.1"}, "x.y.z-P"},
+		{Version{}, "x.y"},
+	} {
+		expected, err := test.v.Format(test.format)
+		assert.NoError(err)
+		var buf bytes.Buffer
+		assert.NoError(test.v.FormatInto(&buf, test.format))
+		assert.Equal(expected, buf.String())
+	}
+
+	var buf bytes.Buffer
+	assert.Error(Version{}.FormatInto(&buf, "x.y.z-q"))
+	assert.Error(Version{preRelease: "alpha"}.FormatInto(&buf, "x.y.z-r"))
+}
+
+func TestFormatterWithDecorator(t *testing.T) {
+	assert := assert.New(t)
+	f, err := NewFormatter(FullFormat)
+	assert.NoError(err)
+	d := f.WithMetaSeparator("_").WithDecorator(func(verb byte, s string) string {
+		return "<" + string(verb) + ":" + s + ">"
+	})
+	for _, test := range []struct {
+		v Version
+		s string
+	}{
+		{Version{Prefix: "v", Major: 1, Minor: 2, Patch: 3}, "v<x:1>.<y:2>.<z:3>"},
+		{Version{Major: 1, Minor: 2, Patch: 3, Commits: 2, Meta: "fcf2c8fa"}, "<x:1>.<y:2>.<z:4>-<p:dev.2>_<m:fcf2c8fa>"},
+	} {
+		s, err := d.Format(test.v)
+		assert.NoError(err)
+		assert.Equal(test.s, s)
+		var buf bytes.Buffer
+		assert.NoError(d.FormatInto(&buf, test.v))
+		assert.Equal(test.s, buf.String())
+	}
+	s, err := f.Format(Version{Major: 1, Minor: 2, Patch: 3})
+	assert.NoError(err)
+	assert.Equal("1.2.3", s)
+}
@@ -1,30 +1,97 @@
 package version
 
 import (
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/osfs"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/cache"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/storage"
+	"github.com/go-git/go-git/v5/storage/filesystem"
 )
 
 // RepoHead provides statistics about the head commit of a git
 // repository like its commit-ash, the number of commits since
-// the last tag and the name of the last tag.
+// the last tag and the name of the last tag. If multiple tags
+// point to the same commit, Tags holds all of them and LastTag
+// the one with the highest precedence. Branch holds the name of
+// the checked out branch and is empty for a detached HEAD.
+// CommitTime is the committer date of the head commit, or its
+// author date if WithAuthorDate is used. TotalCommits is the
+// number of all commits reachable from HEAD, which is only
+// counted with WithTotalCommits or if there is no tag, whereas
+// CommitsSinceTag is 0 if HEAD is tagged. Dirty is set by
+// NewFromDescribe for the output of git describe --dirty.
+// TagTime is the tagger date of LastTag, or the committer date
+// of the tagged commit for lightweight tags.
 type RepoHead struct {
 	LastTag         string
+	Tags            []string
 	CommitsSinceTag int
+	TotalCommits    int
 	Hash            string
+	Branch          string
+	CommitTime      time.Time
+	TagTime         time.Time
+	Dirty           bool
 }
 
 // GitDescribe looks at the git respository at path and figures
-// out versioning relvant information about the head commit.
-func GitDescribe(path string) (*RepoHead, error) {
-	repo, err := git.PlainOpen(path)
+// out versioning relvant information about the head commit. If
+// there is no tag at all, CommitsSinceTag holds the number of all
+// commits reachable from HEAD.
+func GitDescribe(path string, opts ...Option) (*RepoHead, error) {
+	repo, err := openRepo(path, newOptions(opts))
 	if err != nil {
 		return nil, fmt.Errorf("failed to open repo: %w", err)
 	}
+	return GitDescribeRepository(repo, opts...)
+}
+
+// openRepo opens the repository at path. If path is a linked worktree created
+// with git worktree add, its .git file is followed and the refs and objects are
+// read from the common directory of the main repository, whereas HEAD is the one
+// of the linked worktree. With WithWorkTree, path is the git directory itself.
+func openRepo(path string, o *options) (*git.Repository, error) {
+	if o.workTree != "" {
+		s := filesystem.NewStorage(osfs.New(path), cache.NewObjectLRUDefault())
+		return git.Open(s, osfs.New(o.workTree))
+	}
+	return git.PlainOpenWithOptions(path, &git.PlainOpenOptions{EnableDotGitCommonDir: true})
+}
+
+// GitDescribeFS is like GitDescribe for a repository that is stored in s with
+// the worktree in fs, e.g. an in-memory repository. The worktree may be nil for
+// bare repositories.
+func GitDescribeFS(fs billy.Filesystem, s storage.Storer, opts ...Option) (*RepoHead, error) {
+	repo, err := git.Open(s, fs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repo: %w", err)
+	}
+	return GitDescribeRepository(repo, opts...)
+}
+
+// GitDescribeRepository is like GitDescribe for an already opened repository.
+func GitDescribeRepository(repo *git.Repository, opts ...Option) (*RepoHead, error) {
+	o := newOptions(opts)
+	if o.cache {
+		return cachedDescribe(repo, o)
+	}
+	return describe(repo, o)
+}
+
+// describe is replaced in tests to observe the calls that bypass the cache.
+var describe = describeRepository
+
+func describeRepository(repo *git.Repository, o *options) (*RepoHead, error) {
 	head, err := repo.Head()
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve repo head: %w", err)
@@ -33,7 +100,18 @@ func GitDescribe(path string) (*RepoHead, error) {
 	ref := RepoHead{
 		Hash: head.Hash().String(),
 	}
-	tags, err := getTagMap(repo)
+	if head.Name().IsBranch() {
+		ref.Branch = head.Name().Short()
+	}
+	headCommit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve head commit: %w", err)
+	}
+	ref.CommitTime = headCommit.Committer.When
+	if o.authorDate {
+		ref.CommitTime = headCommit.Author.When
+	}
+	tags, err := getTagMap(repo, o.allTags)
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve tag-list: %w", err)
 	}
@@ -46,10 +124,24 @@ func GitDescribe(path string) (*RepoHead, error) {
 		return nil, fmt.Errorf("failed to list commits: %w", err)
 	}
 
+	found := false
 	_ = commits.ForEach(func(c *object.Commit) error {
-		ref.LastTag = (*tags)[c.Hash.String()]
-		if ref.LastTag != "" {
-			return storer.ErrStop
+		ref.TotalCommits++
+		if found {
+			return nil
+		}
+		if names := o.filterTags(tags[c.Hash.String()]); len(names) > 0 {
+			sort.Strings(names)
+			ref.Tags = names
+			ref.LastTag = highestTag(names)
+			ref.TagTime = tagTime(repo, ref.LastTag, c)
+			found = true
+			if !o.totalCommits {
+				// the rest of the history is only walked if it is counted
+				ref.TotalCommits = 0
+				return storer.ErrStop
+			}
+			return nil
 		}
 		ref.CommitsSinceTag += 1
 		return nil
@@ -57,13 +149,194 @@ func GitDescribe(path string) (*RepoHead, error) {
 	return &ref, nil
 }
 
-func getTagMap(repo *git.Repository) (*map[string]string, error) {
-	tags, err := repo.Tags()
+// ErrNotDescendant is returned by GitDescribeSince if HEAD is not a descendant of
+// the given tag.
+var ErrNotDescendant = errors.New("HEAD is not a descendant of the tag")
+
+// GitDescribeSince is like GitDescribe but uses the given tag as last tag instead
+// of the nearest one, e.g. to count the commits on a maintenance branch since an
+// older release. CommitsSinceTag is the number of commits reachable from HEAD but
+// not from the tag like git rev-list --count <tag>..HEAD.
+func GitDescribeSince(path, tag string, opts ...Option) (*RepoHead, error) {
+	o := newOptions(opts)
+	repo, err := openRepo(path, o)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repo: %w", err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve repo head: %w", err)
+	}
+	headCommit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve head commit: %w", err)
+	}
+	tagRef, err := repo.Tag(tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve tag %s: %w", tag, err)
+	}
+	tagCommit, err := tagCommit(repo, tagRef)
 	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve commit of tag %s: %w", tag, err)
+	}
+	if ok, err := tagCommit.IsAncestor(headCommit); err != nil {
+		return nil, fmt.Errorf("failed to list commits: %w", err)
+	} else if !ok {
+		return nil, fmt.Errorf("%w %s", ErrNotDescendant, tag)
+	}
+
+	ref := RepoHead{
+		LastTag:    tag,
+		Tags:       []string{tag},
+		Hash:       head.Hash().String(),
+		CommitTime: headCommit.Committer.When,
+		TagTime:    tagTime(repo, tag, tagCommit),
+	}
+	if head.Name().IsBranch() {
+		ref.Branch = head.Name().Short()
+	}
+	if o.authorDate {
+		ref.CommitTime = headCommit.Author.When
+	}
+	tagged := make(map[plumbing.Hash]bool)
+	if err := object.NewCommitPreorderIter(tagCommit, nil, nil).ForEach(func(c *object.Commit) error {
+		tagged[c.Hash] = true
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to list commits: %w", err)
+	}
+	if err := object.NewCommitPreorderIter(headCommit, nil, nil).ForEach(func(c *object.Commit) error {
+		ref.TotalCommits++
+		if !tagged[c.Hash] {
+			ref.CommitsSinceTag++
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to list commits: %w", err)
+	}
+	return &ref, nil
+}
+
+// tagCommit returns the commit that the tag reference points to, either directly
+// or via an annotated tag object.
+func tagCommit(repo *git.Repository, ref *plumbing.Reference) (*object.Commit, error) {
+	tag, err := repo.TagObject(ref.Hash())
+	switch err {
+	case nil:
+		return tag.Commit()
+	case plumbing.ErrObjectNotFound:
+		return repo.CommitObject(ref.Hash())
+	default:
 		return nil, err
 	}
-	result := make(map[string]string)
-	if err = tags.ForEach(func(r *plumbing.Reference) error {
+}
+
+// tagTime returns the tagger date of the annotated tag with the given name, or the
+// committer date of the tagged commit c for lightweight tags and tags fetched from
+// remotes with WithAllTags.
+func tagTime(repo *git.Repository, name string, c *object.Commit) time.Time {
+	if ref, err := repo.Tag(name); err == nil {
+		if tag, err := repo.TagObject(ref.Hash()); err == nil {
+			return tag.Tagger.When
+		}
+	}
+	return c.Committer.When
+}
+
+// highestTag returns the tag with the highest precedence. Tags that can't be
+// parsed as version have the lowest precedence.
+func highestTag(names []string) string {
+	var (
+		best   string
+		bestV  Version
+		bestOK bool
+	)
+	for _, name := range names {
+		v, err := Parse(name)
+		ok := err == nil
+		if best == "" || (ok && !bestOK) || (ok && v.Compare(bestV) >= 0) || (!ok && !bestOK) {
+			best, bestV, bestOK = name, v, ok
+		}
+	}
+	return best
+}
+
+// filterTags returns the tags that are considered by GitDescribe according to the
+// options WithStableTagsOnly, WithMatchRegex, WithSkipNonSemverTags,
+// WithComponent and WithIgnoreTags.
+func (o *options) filterTags(names []string) []string {
+	var result []string
+	for _, name := range names {
+		if o.isIgnoredTag(name) {
+			continue
+		}
+		if o.matchRegex != nil && !o.matchRegex.MatchString(name) {
+			continue
+		}
+		if o.component != "" && !strings.HasPrefix(name, o.component+"/") {
+			continue
+		}
+		if v, err := Parse(name); o.stableTagsOnly && err == nil && v.PreRelease() != "" {
+			continue
+		}
+		if o.skipNonSemver && !o.isSemverTag(name) {
+			continue
+		}
+		result = append(result, name)
+	}
+	return result
+}
+
+// isIgnoredTag reports whether the tag is excluded with WithIgnoreTags.
+func (o *options) isIgnoredTag(name string) bool {
+	if len(o.ignoreTags) == 0 {
+		return false
+	}
+	_, unprefixed := o.splitTag(name)
+	return o.ignoreTags[name] || o.ignoreTags[unprefixed]
+}
+
+// isSemverTag reports whether a version can be derived from the tag with the
+// prefix and short tag handling of the options.
+func (o *options) isSemverTag(name string) bool {
+	v, err := o.newFromHead(&RepoHead{LastTag: name})
+	return err == nil && validatePreRelease(v.preRelease) == nil
+}
+
+// getTagMap maps the hashes of the tagged commits to the names of their tags.
+// Tags are found as loose refs as well as in packed-refs, as they are returned by
+// the reference storage of the repository. If allTags is set, the tags fetched
+// from remotes into refs/remotes/<remote>/tags/ are included as well. A local
+// tag takes precedence over a remote tag with the same name, and among remotes
+// the one whose name sorts first wins.
+func getTagMap(repo *git.Repository, allTags bool) (map[string][]string, error) {
+	refs, err := repo.References()
+	if err != nil {
+		return nil, err
+	}
+	var local, remote []*plumbing.Reference
+	if err = refs.ForEach(func(r *plumbing.Reference) error {
+		switch {
+		case r.Name().IsTag():
+			local = append(local, r)
+		case allTags && remoteTagName(r.Name()) != "":
+			remote = append(remote, r)
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	sort.Slice(remote, func(i, j int) bool {
+		return remote[i].Name() < remote[j].Name()
+	})
+
+	result := make(map[string][]string)
+	seen := make(map[string]bool)
+	add := func(r *plumbing.Reference, name string) error {
+		if seen[name] {
+			return nil
+		}
+		seen[name] = true
 		tag, err := repo.TagObject(r.Hash())
 		switch err {
 		case nil:
@@ -71,15 +344,178 @@ func getTagMap(repo *git.Repository) (*map[string]string, error) {
 			if err != nil {
 				return nil
 			}
-			result[commit.Hash.String()] = tag.Name
+			result[commit.Hash.String()] = append(result[commit.Hash.String()], tag.Name)
 		case plumbing.ErrObjectNotFound:
-			result[r.Hash().String()] = r.Name().Short()
+			result[r.Hash().String()] = append(result[r.Hash().String()], name)
 		default:
-			return err
+			return fmt.Errorf("failed to list tags: %w", err)
+		}
+		return nil
+	}
+	for _, r := range local {
+		if err := add(r, r.Name().Short()); err != nil {
+			return nil, err
+		}
+	}
+	for _, r := range remote {
+		if err := add(r, remoteTagName(r.Name())); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// remoteTagName returns the name of the tag for references like
+// refs/remotes/origin/tags/v1.2.3 and an empty string for all other references.
+func remoteTagName(name plumbing.ReferenceName) string {
+	if !name.IsRemote() {
+		return ""
+	}
+	parts := strings.SplitN(strings.TrimPrefix(name.String(), "refs/remotes/"), "/tags/", 2)
+	if len(parts) != 2 || parts[0] == "" || strings.Contains(parts[0], "/") {
+		return ""
+	}
+	return parts[1]
+}
+
+// ValidateTags returns the names of all tags of the repository at path, that
+// can't be parsed as semantic version by Parse, e.g. v01.2.3 or v1.2.3+a_b. With
+// WithStrictSemver tags with a prefix are reported as well.
+func ValidateTags(path string, opts ...Option) ([]string, error) {
+	o := newOptions(opts)
+	parse := Parse
+	if o.strictSemver {
+		parse = StrictParse
+	}
+	repo, err := openRepo(path, o)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repo: %w", err)
+	}
+	tags, err := repo.Tags()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve tag-list: %w", err)
+	}
+	var invalid []string
+	if err = tags.ForEach(func(r *plumbing.Reference) error {
+		if _, err := parse(r.Name().Short()); err != nil {
+			invalid = append(invalid, r.Name().Short())
 		}
 		return nil
 	}); err != nil {
 		return nil, fmt.Errorf("failed to list tags: %w", err)
 	}
-	return &result, nil
+	sort.Strings(invalid)
+	return invalid, nil
+}
+
+// LatestVersion returns the version of the highest tag in the repository at path,
+// regardless of whether it is reachable from HEAD. Tags that are no valid version
+// are ignored, as well as tags excluded by WithMatchRegex, WithStableTagsOnly or
+// WithIgnoreTags.
+// The version has no commits since the tag and its hash is the tagged commit.
+// ErrNoTags is returned if there is no such tag.
+func LatestVersion(path string, opts ...Option) (Version, error) {
+	o := newOptions(opts)
+	repo, err := openRepo(path, o)
+	if err != nil {
+		return Version{}, fmt.Errorf("failed to open repo: %w", err)
+	}
+	tags, err := getTagMap(repo, o.allTags)
+	if err != nil {
+		return Version{}, fmt.Errorf("failed to retrieve tag-list: %w", err)
+	}
+	var (
+		latest Version
+		found  bool
+	)
+	for hash, names := range tags {
+		for _, name := range o.filterTags(names) {
+			v, err := o.newFromHead(&RepoHead{LastTag: name, Hash: hash})
+			if err != nil || validatePreRelease(v.preRelease) != nil {
+				continue
+			}
+			if !found || v.Compare(latest) > 0 || (v.Compare(latest) == 0 && name > latest.tag) {
+				latest, found = v, true
+			}
+		}
+	}
+	if !found {
+		return Version{}, ErrNoTags
+	}
+	return latest, nil
+}
+
+// VersionsBetween returns the versions of all tags in the repository at path with
+// from < v <= to according to Compare, sorted in ascending order, e.g. to
+// generate a changelog of several releases. A zero from or to leaves the range
+// open on that side. Tags are filtered like for LatestVersion and tags that are
+// no valid version are ignored. Each version has the tagged commit as hash.
+func VersionsBetween(path string, from, to Version, opts ...Option) ([]Version, error) {
+	o := newOptions(opts)
+	repo, err := openRepo(path, o)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repo: %w", err)
+	}
+	tags, err := getTagMap(repo, o.allTags)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve tag-list: %w", err)
+	}
+	var versions []Version
+	for hash, names := range tags {
+		for _, name := range o.filterTags(names) {
+			v, err := o.newFromHead(&RepoHead{LastTag: name, Hash: hash})
+			if err != nil || validatePreRelease(v.preRelease) != nil {
+				continue
+			}
+			if from != (Version{}) && v.Compare(from) <= 0 {
+				continue
+			}
+			if to != (Version{}) && v.Compare(to) > 0 {
+				continue
+			}
+			versions = append(versions, v)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].tag < versions[j].tag
+	})
+	SortVersions(versions)
+	return versions, nil
+}
+
+// commitsSinceTag returns all commits reachable from HEAD of the repository at
+// path that were made after the last tag considered by GitDescribe, starting with
+// HEAD itself.
+func commitsSinceTag(path string, o *options) ([]*object.Commit, error) {
+	repo, err := openRepo(path, o)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repo: %w", err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve repo head: %w", err)
+	}
+	tags, err := getTagMap(repo, o.allTags)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve tag-list: %w", err)
+	}
+	log, err := repo.Log(&git.LogOptions{
+		From:  head.Hash(),
+		Order: git.LogOrderCommitterTime,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits: %w", err)
+	}
+	var commits []*object.Commit
+	err = log.ForEach(func(c *object.Commit) error {
+		if len(o.filterTags(tags[c.Hash.String()])) > 0 {
+			return storer.ErrStop
+		}
+		commits = append(commits, c)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits: %w", err)
+	}
+	return commits, nil
 }
@@ -1,11 +1,19 @@
 package version
 
 import (
+	"errors"
 	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
 	"testing"
+	"time"
 
+	"github.com/go-git/go-billy/v5/memfs"
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -19,9 +27,22 @@ func TestGitDescribe(t *testing.T) {
 	assert.NoError(err)
 
 	test := func(expected *RepoHead) {
-		actual, err := GitDescribe(dir)
+		actual, err := GitDescribe(dir, WithTotalCommits())
 		assert.NoError(err)
+		assert.False(actual.CommitTime.IsZero())
+		assert.Equal(actual.LastTag != "", !actual.TagTime.IsZero())
+		actual.CommitTime, actual.TagTime = time.Time{}, time.Time{}
 		assert.Equal(expected, actual)
+
+		// without WithTotalCommits the history is walked up to the tag only
+		actual, err = GitDescribe(dir)
+		assert.NoError(err)
+		if expected.LastTag != "" {
+			assert.Equal(0, actual.TotalCommits)
+		} else {
+			assert.Equal(expected.TotalCommits, actual.TotalCommits)
+		}
+		assert.Equal(expected.CommitsSinceTag, actual.CommitsSinceTag)
 	}
 
 	author := &object.Signature{
@@ -32,14 +53,17 @@ func TestGitDescribe(t *testing.T) {
 
 	commit1, err := worktree.Commit("first commit", &opts)
 	assert.NoError(err)
-	test(&RepoHead{Hash: commit1.String(), CommitsSinceTag: 1})
+	test(&RepoHead{Hash: commit1.String(), CommitsSinceTag: 1, TotalCommits: 1, Branch: "master"})
 
 	tag1, err := repo.CreateTag("1.0.0", commit1, nil)
 	assert.NoError(err)
 	test(&RepoHead{
 		LastTag:         tag1.Name().Short(),
+		Tags:            []string{"1.0.0"},
 		Hash:            commit1.String(),
+		TotalCommits:    1,
 		CommitsSinceTag: 0,
+		Branch:          "master",
 	})
 
 	tag1Post, err := repo.CreateTag("v1.0.0", commit1, &git.CreateTagOptions{
@@ -49,16 +73,22 @@ func TestGitDescribe(t *testing.T) {
 	assert.NoError(err)
 	test(&RepoHead{
 		LastTag:         tag1Post.Name().Short(),
+		Tags:            []string{"1.0.0", "v1.0.0"},
 		Hash:            commit1.String(),
+		TotalCommits:    1,
 		CommitsSinceTag: 0,
+		Branch:          "master",
 	})
 
 	commit2, err := worktree.Commit("second commit", &opts)
 	assert.NoError(err)
 	test(&RepoHead{
 		LastTag:         tag1Post.Name().Short(),
+		Tags:            []string{"1.0.0", "v1.0.0"},
 		Hash:            commit2.String(),
+		TotalCommits:    2,
 		CommitsSinceTag: 1,
+		Branch:          "master",
 	})
 
 	tag2, err := repo.CreateTag("v2.0.0-rc.1", commit2, &git.CreateTagOptions{
@@ -68,11 +98,161 @@ func TestGitDescribe(t *testing.T) {
 	assert.NoError(err)
 	test(&RepoHead{
 		LastTag:         tag2.Name().Short(),
+		Tags:            []string{"v2.0.0-rc.1"},
 		Hash:            commit2.String(),
+		TotalCommits:    2,
 		CommitsSinceTag: 0,
+		Branch:          "master",
+	})
+
+	err = worktree.Checkout(&git.CheckoutOptions{
+		Branch: plumbing.NewBranchReferenceName("feature/x"),
+		Create: true,
+	})
+	assert.NoError(err)
+	later := *author
+	later.When = time.Now().Add(time.Minute)
+	commit3, err := worktree.Commit("third commit", &git.CommitOptions{Author: &later})
+	assert.NoError(err)
+	test(&RepoHead{
+		LastTag:         tag2.Name().Short(),
+		Tags:            []string{"v2.0.0-rc.1"},
+		Hash:            commit3.String(),
+		TotalCommits:    3,
+		CommitsSinceTag: 1,
+		Branch:          "feature/x",
+	})
+
+	err = worktree.Checkout(&git.CheckoutOptions{Hash: commit3})
+	assert.NoError(err)
+	test(&RepoHead{
+		LastTag:         tag2.Name().Short(),
+		Tags:            []string{"v2.0.0-rc.1"},
+		Hash:            commit3.String(),
+		TotalCommits:    3,
+		CommitsSinceTag: 1,
 	})
 }
 
+func TestGitDescribeUntagged(t *testing.T) {
+	assert := assert.New(t)
+	dir, _ := ioutil.TempDir("", "example")
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(err)
+
+	worktree, err := repo.Worktree()
+	assert.NoError(err)
+
+	var head plumbing.Hash
+	for i, msg := range []string{"first commit", "second commit", "third commit"} {
+		head, err = worktree.Commit(msg, &git.CommitOptions{Author: &object.Signature{
+			Name:  "John Doe",
+			Email: "john@doe.org",
+			When:  time.Unix(int64(i), 0),
+		}})
+		assert.NoError(err)
+	}
+
+	ref, err := GitDescribe(dir)
+	assert.NoError(err)
+	assert.True(time.Unix(2, 0).Equal(ref.CommitTime))
+	ref.CommitTime = time.Time{}
+	assert.Equal(&RepoHead{Hash: head.String(), CommitsSinceTag: 3, TotalCommits: 3, Branch: "master"}, ref)
+
+	v, err := NewFromRepo(dir)
+	assert.NoError(err)
+	assert.Equal("0.0.1-dev.3+"+head.String()[:8], v.String())
+}
+
+func TestNewFromRepoWithoutPrefix(t *testing.T) {
+	assert := assert.New(t)
+	dir, _ := ioutil.TempDir("", "example")
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(err)
+
+	worktree, err := repo.Worktree()
+	assert.NoError(err)
+	commit, err := worktree.Commit("first commit", &git.CommitOptions{Author: &object.Signature{
+		Name:  "John Doe",
+		Email: "john@doe.org",
+	}})
+	assert.NoError(err)
+	_, err = repo.CreateTag("v1.2.3", commit, nil)
+	assert.NoError(err)
+
+	v, err := NewFromRepo(dir)
+	assert.NoError(err)
+	assert.Equal("v1.2.3", v.String())
+
+	v, err = NewFromRepo(dir, WithoutPrefix())
+	assert.NoError(err)
+	assert.Equal("1.2.3", v.String())
+}
+
+func TestGitDescribeConflictingTags(t *testing.T) {
+	assert := assert.New(t)
+	dir, _ := ioutil.TempDir("", "example")
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(err)
+
+	worktree, err := repo.Worktree()
+	assert.NoError(err)
+	commit, err := worktree.Commit("first commit", &git.CommitOptions{Author: &object.Signature{
+		Name:  "John Doe",
+		Email: "john@doe.org",
+	}})
+	assert.NoError(err)
+	for _, tag := range []string{"v2.0.0", "v1.2.3", "nightly"} {
+		_, err = repo.CreateTag(tag, commit, nil)
+		assert.NoError(err)
+	}
+
+	ref, err := GitDescribe(dir)
+	assert.NoError(err)
+	assert.Equal("v2.0.0", ref.LastTag)
+	assert.Equal([]string{"nightly", "v1.2.3", "v2.0.0"}, ref.Tags)
+
+	v, err := NewFromRepo(dir)
+	assert.NoError(err)
+	assert.Equal("v2.0.0", v.String())
+
+	_, err = NewFromRepo(dir, WithStrictTags())
+	assert.EqualError(err, "conflicting tags point to the same commit: v1.2.3, v2.0.0")
+}
+
+func TestValidateTags(t *testing.T) {
+	assert := assert.New(t)
+	dir, _ := ioutil.TempDir("", "example")
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(err)
+
+	worktree, err := repo.Worktree()
+	assert.NoError(err)
+	commit, err := worktree.Commit("first commit", &git.CommitOptions{Author: &object.Signature{
+		Name:  "John Doe",
+		Email: "john@doe.org",
+	}})
+	assert.NoError(err)
+
+	invalid, err := ValidateTags(dir)
+	assert.NoError(err)
+	assert.Empty(invalid)
+
+	for _, tag := range []string{"v1.0.0", "1.1.0-rc.1", "v2.0", "nightly", "v1.2.3+build.1", "release-1.2.3", "v01.2.3", "v1.2.3+a_b", "v1.2.3+a+b", "v1.2.3-"} {
+		_, err = repo.CreateTag(tag, commit, nil)
+		assert.NoError(err)
+	}
+	invalid, err = ValidateTags(dir)
+	assert.NoError(err)
+	assert.Equal([]string{"nightly", "release-1.2.3", "v01.2.3", "v1.2.3+a+b", "v1.2.3+a_b", "v1.2.3-", "v2.0"}, invalid)
+	invalid, err = ValidateTags(dir, WithStrictSemver())
+	assert.NoError(err)
+	assert.Equal([]string{"nightly", "release-1.2.3", "v01.2.3", "v1.0.0", "v1.2.3+a+b", "v1.2.3+a_b", "v1.2.3+build.1", "v1.2.3-", "v2.0"}, invalid)
+
+	_, err = ValidateTags(filepath.Join(dir, "missing"))
+	assert.EqualError(err, "failed to open repo: repository does not exist")
+}
+
 func TestGitDescribeError(t *testing.T) {
 	assert := assert.New(t)
 	dir, _ := ioutil.TempDir("", "example")
@@ -88,3 +268,626 @@ func TestGitDescribeError(t *testing.T) {
 	assert.NoError(err)
 	test("failed to retrieve repo head: reference not found")
 }
+
+func TestGitDescribeCommitTime(t *testing.T) {
+	assert := assert.New(t)
+	dir, _ := ioutil.TempDir("", "example")
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(err)
+	worktree, err := repo.Worktree()
+	assert.NoError(err)
+
+	authored := time.Date(2020, 12, 1, 10, 30, 0, 0, time.UTC)
+	committed := time.Date(2020, 12, 8, 16, 45, 0, 0, time.UTC)
+	_, err = worktree.Commit("cherry-picked commit", &git.CommitOptions{
+		Author:    &object.Signature{Name: "John Doe", Email: "john@doe.org", When: authored},
+		Committer: &object.Signature{Name: "Jane Doe", Email: "jane@doe.org", When: committed},
+	})
+	assert.NoError(err)
+
+	head, err := GitDescribe(dir)
+	assert.NoError(err)
+	assert.True(committed.Equal(head.CommitTime))
+	head, err = GitDescribe(dir, WithAuthorDate())
+	assert.NoError(err)
+	assert.True(authored.Equal(head.CommitTime))
+}
+
+func TestGitDescribeStableTagsOnly(t *testing.T) {
+	assert := assert.New(t)
+	dir, _ := ioutil.TempDir("", "example")
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(err)
+
+	worktree, err := repo.Worktree()
+	assert.NoError(err)
+	var head plumbing.Hash
+	for i, tag := range []string{"v1.9.0", "", "v2.0.0-rc.1", ""} {
+		head, err = worktree.Commit("commit", &git.CommitOptions{Author: &object.Signature{
+			Name:  "John Doe",
+			Email: "john@doe.org",
+			When:  time.Unix(int64(i), 0),
+		}})
+		assert.NoError(err)
+		if tag != "" {
+			_, err = repo.CreateTag(tag, head, nil)
+			assert.NoError(err)
+		}
+	}
+
+	ref, err := GitDescribe(dir)
+	assert.NoError(err)
+	assert.Equal("v2.0.0-rc.1", ref.LastTag)
+	assert.Equal(1, ref.CommitsSinceTag)
+
+	ref, err = GitDescribe(dir, WithStableTagsOnly())
+	assert.NoError(err)
+	assert.Equal("v1.9.0", ref.LastTag)
+	assert.Equal(3, ref.CommitsSinceTag)
+
+	v, err := NewFromRepo(dir, WithStableTagsOnly())
+	assert.NoError(err)
+	assert.Equal("v1.9.1-dev.3+"+head.String()[:8], v.String())
+}
+
+func TestNewFromRepoSkipNonSemverTags(t *testing.T) {
+	assert := assert.New(t)
+	dir, _ := ioutil.TempDir("", "example")
+	defer os.RemoveAll(dir)
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(err)
+
+	worktree, err := repo.Worktree()
+	assert.NoError(err)
+	var head plumbing.Hash
+	for i, tag := range []string{"v1.0.0", "", "nightly-20240101", ""} {
+		head, err = worktree.Commit("commit", &git.CommitOptions{Author: &object.Signature{
+			Name:  "John Doe",
+			Email: "john@doe.org",
+			When:  time.Unix(int64(i), 0),
+		}})
+		assert.NoError(err)
+		if tag != "" {
+			_, err = repo.CreateTag(tag, head, nil)
+			assert.NoError(err)
+		}
+	}
+
+	_, err = NewFromRepo(dir)
+	assert.Error(err)
+
+	ref, err := GitDescribe(dir, WithSkipNonSemverTags())
+	assert.NoError(err)
+	assert.Equal("v1.0.0", ref.LastTag)
+	assert.Equal(3, ref.CommitsSinceTag)
+
+	v, err := NewFromRepo(dir, WithSkipNonSemverTags())
+	assert.NoError(err)
+	assert.Equal("v1.0.1-dev.3+"+head.String()[:8], v.String())
+}
+
+func TestNewFromRepoComponent(t *testing.T) {
+	assert := assert.New(t)
+	dir, _ := ioutil.TempDir("", "example")
+	defer os.RemoveAll(dir)
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(err)
+
+	worktree, err := repo.Worktree()
+	assert.NoError(err)
+	var head plumbing.Hash
+	for i, tag := range []string{"api/v1.2.3", "", "web/v2.0.0", ""} {
+		head, err = worktree.Commit("commit", &git.CommitOptions{Author: &object.Signature{
+			Name:  "John Doe",
+			Email: "john@doe.org",
+			When:  time.Unix(int64(i), 0),
+		}})
+		assert.NoError(err)
+		if tag != "" {
+			_, err = repo.CreateTag(tag, head, nil)
+			assert.NoError(err)
+		}
+	}
+
+	v, err := NewFromRepo(dir)
+	assert.NoError(err)
+	assert.Equal("web/v2.0.1-dev.1+"+head.String()[:8], v.String())
+
+	v, err = NewFromRepo(dir, WithComponent("api"))
+	assert.NoError(err)
+	assert.Equal("api/v1.2.4-dev.3+"+head.String()[:8], v.String())
+
+	v, err = NewFromRepo(dir, WithComponent("cli"))
+	assert.NoError(err)
+	assert.Equal("cli/0.0.1-dev.4+"+head.String()[:8], v.String())
+}
+
+func TestNewFromRepoMatchRegex(t *testing.T) {
+	assert := assert.New(t)
+	dir, _ := ioutil.TempDir("", "example")
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(err)
+
+	worktree, err := repo.Worktree()
+	assert.NoError(err)
+	var head plumbing.Hash
+	for i, tag := range []string{"api/v1.2.0", "web/v2.0.0", ""} {
+		head, err = worktree.Commit("commit", &git.CommitOptions{Author: &object.Signature{
+			Name:  "John Doe",
+			Email: "john@doe.org",
+			When:  time.Unix(int64(i), 0),
+		}})
+		assert.NoError(err)
+		if tag != "" {
+			_, err = repo.CreateTag(tag, head, nil)
+			assert.NoError(err)
+		}
+	}
+
+	re := regexp.MustCompile(`^api/(.+)$`)
+	ref, err := GitDescribe(dir, WithMatchRegex(re))
+	assert.NoError(err)
+	assert.Equal("api/v1.2.0", ref.LastTag)
+	assert.Equal(2, ref.CommitsSinceTag)
+
+	v, err := NewFromRepo(dir, WithMatchRegex(re))
+	assert.NoError(err)
+	assert.Equal("api/v1.2.1-dev.2+"+head.String()[:8], v.String())
+
+	v, err = NewFromRepo(dir, WithMatchRegex(re), WithoutPrefix())
+	assert.NoError(err)
+	assert.Equal("1.2.1-dev.2+"+head.String()[:8], v.String())
+
+	v, err = NewFromRepo(dir, WithMatchRegex(regexp.MustCompile(`^web/(.+)$`)))
+	assert.NoError(err)
+	assert.Equal("web/v2.0.1-dev.1+"+head.String()[:8], v.String())
+}
+
+func TestGitDescribePackedRefs(t *testing.T) {
+	assert := assert.New(t)
+	dir, _ := ioutil.TempDir("", "example")
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(err)
+
+	worktree, err := repo.Worktree()
+	assert.NoError(err)
+	author := &object.Signature{Name: "John Doe", Email: "john@doe.org"}
+	var commits []plumbing.Hash
+	for i := 0; i < 3; i++ {
+		author.When = time.Unix(int64(i), 0)
+		commit, err := worktree.Commit("commit", &git.CommitOptions{Author: author})
+		assert.NoError(err)
+		commits = append(commits, commit)
+	}
+	lightweight, err := repo.CreateTag("v1.0.0", commits[0], nil)
+	assert.NoError(err)
+	annotated, err := repo.CreateTag("v1.1.0", commits[1], &git.CreateTagOptions{
+		Tagger:  author,
+		Message: "annotated tag",
+	})
+	assert.NoError(err)
+
+	// move the tags from loose refs to packed-refs like git gc does
+	packed := "# pack-refs with: peeled fully-peeled sorted \n" +
+		lightweight.Hash().String() + " refs/tags/v1.0.0\n" +
+		annotated.Hash().String() + " refs/tags/v1.1.0\n" +
+		"^" + commits[1].String() + "\n"
+	assert.NoError(ioutil.WriteFile(filepath.Join(dir, ".git", "packed-refs"), []byte(packed), 0644))
+	for _, tag := range []string{"v1.0.0", "v1.1.0"} {
+		assert.NoError(os.Remove(filepath.Join(dir, ".git", "refs", "tags", tag)))
+	}
+
+	ref, err := GitDescribe(dir, WithTotalCommits())
+	assert.NoError(err)
+	assert.Equal("v1.1.0", ref.LastTag)
+	assert.Equal(1, ref.CommitsSinceTag)
+	assert.Equal(3, ref.TotalCommits)
+
+	invalid, err := ValidateTags(dir)
+	assert.NoError(err)
+	assert.Empty(invalid)
+}
+
+func TestNewFromRepoRequiredTags(t *testing.T) {
+	assert := assert.New(t)
+	dir, _ := ioutil.TempDir("", "example")
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(err)
+
+	worktree, err := repo.Worktree()
+	assert.NoError(err)
+	commit, err := worktree.Commit("first commit", &git.CommitOptions{Author: &object.Signature{
+		Name:  "John Doe",
+		Email: "john@doe.org",
+	}})
+	assert.NoError(err)
+
+	v, err := NewFromRepo(dir)
+	assert.NoError(err)
+	assert.Equal("0.0.1-dev.1+"+commit.String()[:8], v.String())
+	_, err = NewFromRepo(dir, WithRequiredTags())
+	assert.True(errors.Is(err, ErrNoTags))
+
+	_, err = repo.CreateTag("v1.2.3", commit, nil)
+	assert.NoError(err)
+	v, err = NewFromRepo(dir, WithRequiredTags())
+	assert.NoError(err)
+	assert.Equal("v1.2.3", v.String())
+}
+
+func TestGitDescribeFS(t *testing.T) {
+	assert := assert.New(t)
+	fs := memfs.New()
+	storage := memory.NewStorage()
+	repo, err := git.Init(storage, fs)
+	assert.NoError(err)
+
+	worktree, err := repo.Worktree()
+	assert.NoError(err)
+	var commits []plumbing.Hash
+	for i := 0; i < 2; i++ {
+		commit, err := worktree.Commit("commit", &git.CommitOptions{Author: &object.Signature{
+			Name:  "John Doe",
+			Email: "john@doe.org",
+			When:  time.Unix(int64(i), 0),
+		}})
+		assert.NoError(err)
+		commits = append(commits, commit)
+	}
+	_, err = repo.CreateTag("v1.2.3", commits[0], nil)
+	assert.NoError(err)
+
+	ref, err := GitDescribeFS(fs, storage)
+	assert.NoError(err)
+	assert.Equal("v1.2.3", ref.LastTag)
+	assert.Equal(1, ref.CommitsSinceTag)
+	assert.Equal(commits[1].String(), ref.Hash)
+
+	ref, err = GitDescribeRepository(repo)
+	assert.NoError(err)
+	assert.Equal("v1.2.3", ref.LastTag)
+
+	_, err = GitDescribeFS(memfs.New(), memory.NewStorage())
+	assert.Error(err)
+}
+
+func TestGitDescribeAllTags(t *testing.T) {
+	assert := assert.New(t)
+	dir, _ := ioutil.TempDir("", "example")
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(err)
+
+	worktree, err := repo.Worktree()
+	assert.NoError(err)
+	var commits []plumbing.Hash
+	for i := 0; i < 3; i++ {
+		commit, err := worktree.Commit("commit", &git.CommitOptions{Author: &object.Signature{
+			Name:  "John Doe",
+			Email: "john@doe.org",
+			When:  time.Unix(int64(i), 0),
+		}})
+		assert.NoError(err)
+		commits = append(commits, commit)
+	}
+	_, err = repo.CreateTag("v1.0.0", commits[0], nil)
+	assert.NoError(err)
+	for _, ref := range []*plumbing.Reference{
+		plumbing.NewHashReference("refs/remotes/upstream/tags/v1.1.0", commits[1]),
+		// the local tag v1.0.0 takes precedence
+		plumbing.NewHashReference("refs/remotes/upstream/tags/v1.0.0", commits[2]),
+	} {
+		assert.NoError(repo.Storer.SetReference(ref))
+	}
+
+	ref, err := GitDescribe(dir)
+	assert.NoError(err)
+	assert.Equal("v1.0.0", ref.LastTag)
+	assert.Equal(2, ref.CommitsSinceTag)
+
+	ref, err = GitDescribe(dir, WithAllTags())
+	assert.NoError(err)
+	assert.Equal("v1.1.0", ref.LastTag)
+	assert.Equal(1, ref.CommitsSinceTag)
+}
+
+func TestLatestVersion(t *testing.T) {
+	assert := assert.New(t)
+	dir, _ := ioutil.TempDir("", "example")
+	defer os.RemoveAll(dir)
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(err)
+	worktree, err := repo.Worktree()
+	assert.NoError(err)
+
+	_, err = LatestVersion(dir)
+	assert.Equal(ErrNoTags, err)
+
+	var commits []plumbing.Hash
+	for i := 0; i < 4; i++ {
+		commit, err := worktree.Commit("commit", &git.CommitOptions{Author: &object.Signature{
+			Name:  "John Doe",
+			Email: "john@doe.org",
+			When:  time.Unix(int64(i), 0),
+		}})
+		assert.NoError(err)
+		commits = append(commits, commit)
+	}
+	for tag, commit := range map[string]plumbing.Hash{
+		"v1.0.0":      commits[0],
+		"v1.10.0":     commits[1],
+		"v2.0.0-rc.1": commits[2],
+		"nightly":     commits[3],
+		"v1.9.0":      commits[3],
+	} {
+		_, err = repo.CreateTag(tag, commit, nil)
+		assert.NoError(err)
+	}
+	// move HEAD behind the newest tags
+	assert.NoError(worktree.Checkout(&git.CheckoutOptions{Hash: commits[0]}))
+
+	v, err := NewFromRepo(dir)
+	assert.NoError(err)
+	assert.Equal("v1.0.0", v.String())
+
+	v, err = LatestVersion(dir)
+	assert.NoError(err)
+	assert.Equal("v2.0.0-rc.1", v.String())
+	assert.Equal(0, v.Commits)
+	assert.Equal(commits[2].String(), v.Hash)
+
+	v, err = LatestVersion(dir, WithStableTagsOnly())
+	assert.NoError(err)
+	assert.Equal("v1.10.0", v.String())
+}
+
+func TestNewFromRepoIgnoreTags(t *testing.T) {
+	assert := assert.New(t)
+	dir, _ := ioutil.TempDir("", "example")
+	defer os.RemoveAll(dir)
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(err)
+
+	worktree, err := repo.Worktree()
+	assert.NoError(err)
+	var head plumbing.Hash
+	for i, tag := range []string{"v1.2.0", "", "v9.9.9", ""} {
+		head, err = worktree.Commit("commit", &git.CommitOptions{Author: &object.Signature{
+			Name:  "John Doe",
+			Email: "john@doe.org",
+			When:  time.Unix(int64(i), 0),
+		}})
+		assert.NoError(err)
+		if tag != "" {
+			_, err = repo.CreateTag(tag, head, nil)
+			assert.NoError(err)
+		}
+	}
+
+	v, err := NewFromRepo(dir)
+	assert.NoError(err)
+	assert.Equal("v9.9.10-dev.1+"+head.String()[:8], v.String())
+
+	for _, name := range []string{"v9.9.9", "9.9.9"} {
+		v, err = NewFromRepo(dir, WithIgnoreTags("v0.1.0", name))
+		assert.NoError(err)
+		assert.Equal("v1.2.1-dev.3+"+head.String()[:8], v.String())
+
+		v, err = LatestVersion(dir, WithIgnoreTags(name))
+		assert.NoError(err)
+		assert.Equal("v1.2.0", v.String())
+	}
+}
+
+func TestGitDescribeLinkedWorktree(t *testing.T) {
+	assert := assert.New(t)
+	dir, _ := ioutil.TempDir("", "example")
+	defer os.RemoveAll(dir)
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(err)
+
+	worktree, err := repo.Worktree()
+	assert.NoError(err)
+	var commits []plumbing.Hash
+	for i, tag := range []string{"v1.0.0", "", "v1.1.0"} {
+		commit, err := worktree.Commit("commit", &git.CommitOptions{Author: &object.Signature{
+			Name:  "John Doe",
+			Email: "john@doe.org",
+			When:  time.Unix(int64(i), 0),
+		}})
+		assert.NoError(err)
+		if tag != "" {
+			_, err = repo.CreateTag(tag, commit, nil)
+			assert.NoError(err)
+		}
+		commits = append(commits, commit)
+	}
+
+	// lay out a linked worktree like git worktree add --detach would do
+	linked, _ := ioutil.TempDir("", "example")
+	defer os.RemoveAll(linked)
+	gitDir := filepath.Join(dir, ".git", "worktrees", "linked")
+	assert.NoError(os.MkdirAll(gitDir, 0755))
+	for name, content := range map[string]string{
+		filepath.Join(gitDir, "HEAD"):      commits[1].String(),
+		filepath.Join(gitDir, "commondir"): "../..",
+		filepath.Join(gitDir, "gitdir"):    filepath.Join(linked, ".git"),
+		filepath.Join(linked, ".git"):      "gitdir: " + gitDir,
+	} {
+		assert.NoError(ioutil.WriteFile(name, []byte(content+"\n"), 0644))
+	}
+
+	v, err := NewFromRepo(dir)
+	assert.NoError(err)
+	assert.Equal("v1.1.0", v.String())
+
+	ref, err := GitDescribe(linked)
+	assert.NoError(err)
+	assert.Equal(commits[1].String(), ref.Hash)
+	assert.Equal("v1.0.0", ref.LastTag)
+	assert.Equal(1, ref.CommitsSinceTag)
+
+	v, err = NewFromRepo(linked)
+	assert.NoError(err)
+	assert.Equal("v1.0.1-dev.1+"+commits[1].String()[:8], v.String())
+}
+
+func TestNewFromRepoSince(t *testing.T) {
+	assert := assert.New(t)
+	dir, _ := ioutil.TempDir("", "example")
+	defer os.RemoveAll(dir)
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(err)
+
+	worktree, err := repo.Worktree()
+	assert.NoError(err)
+	var head plumbing.Hash
+	for i, tag := range []string{"v1.0.0", "", "v1.1.0", "", ""} {
+		head, err = worktree.Commit("commit", &git.CommitOptions{Author: &object.Signature{
+			Name:  "John Doe",
+			Email: "john@doe.org",
+			When:  time.Unix(int64(i), 0),
+		}})
+		assert.NoError(err)
+		if tag != "" {
+			_, err = repo.CreateTag(tag, head, &git.CreateTagOptions{
+				Tagger:  &object.Signature{Name: "John Doe", Email: "john@doe.org", When: time.Unix(int64(i), 0)},
+				Message: "Release " + tag,
+			})
+			assert.NoError(err)
+		}
+	}
+
+	v, err := NewFromRepo(dir)
+	assert.NoError(err)
+	assert.Equal("v1.1.1-dev.2+"+head.String()[:8], v.String())
+
+	ref, err := GitDescribeSince(dir, "v1.0.0")
+	assert.NoError(err)
+	assert.Equal("v1.0.0", ref.LastTag)
+	assert.Equal(4, ref.CommitsSinceTag)
+	assert.Equal(5, ref.TotalCommits)
+
+	v, err = NewFromRepoSince(dir, "v1.0.0")
+	assert.NoError(err)
+	assert.Equal("v1.0.1-dev.4+"+head.String()[:8], v.String())
+
+	_, err = NewFromRepoSince(dir, "v2.0.0")
+	assert.Error(err)
+
+	first, err := repo.Tag("v1.0.0")
+	assert.NoError(err)
+	tag, err := repo.TagObject(first.Hash())
+	assert.NoError(err)
+	assert.NoError(worktree.Checkout(&git.CheckoutOptions{Hash: tag.Target}))
+	_, err = NewFromRepoSince(dir, "v1.1.0")
+	assert.True(errors.Is(err, ErrNotDescendant))
+	assert.EqualError(err, "HEAD is not a descendant of the tag v1.1.0")
+}
+
+func TestGitDescribeTagTime(t *testing.T) {
+	assert := assert.New(t)
+	dir, _ := ioutil.TempDir("", "example")
+	defer os.RemoveAll(dir)
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(err)
+
+	worktree, err := repo.Worktree()
+	assert.NoError(err)
+	commitTime := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	first, err := worktree.Commit("commit", &git.CommitOptions{Author: &object.Signature{
+		Name:  "John Doe",
+		Email: "john@doe.org",
+		When:  commitTime,
+	}})
+	assert.NoError(err)
+	_, err = repo.CreateTag("v1.0.0", first, nil)
+	assert.NoError(err)
+
+	ref, err := GitDescribe(dir)
+	assert.NoError(err)
+	assert.True(commitTime.Equal(ref.TagTime), ref.TagTime)
+
+	second, err := worktree.Commit("commit", &git.CommitOptions{Author: &object.Signature{
+		Name:  "John Doe",
+		Email: "john@doe.org",
+		When:  commitTime.Add(time.Hour),
+	}})
+	assert.NoError(err)
+	tagTime := time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC)
+	_, err = repo.CreateTag("v1.1.0", second, &git.CreateTagOptions{
+		Tagger:  &object.Signature{Name: "John Doe", Email: "john@doe.org", When: tagTime},
+		Message: "Release v1.1.0",
+	})
+	assert.NoError(err)
+	_, err = worktree.Commit("commit", &git.CommitOptions{Author: &object.Signature{
+		Name:  "John Doe",
+		Email: "john@doe.org",
+		When:  commitTime.Add(2 * time.Hour),
+	}})
+	assert.NoError(err)
+
+	ref, err = GitDescribe(dir)
+	assert.NoError(err)
+	assert.Equal("v1.1.0", ref.LastTag)
+	assert.True(tagTime.Equal(ref.TagTime), ref.TagTime)
+
+	v, err := NewFromRepo(dir)
+	assert.NoError(err)
+	assert.True(tagTime.Equal(v.TagTime))
+	meta, err := ExpandMetaTemplate("{tagtime}", v)
+	assert.NoError(err)
+	assert.Equal("20240201090000", meta)
+
+	ref, err = GitDescribeSince(dir, "v1.0.0")
+	assert.NoError(err)
+	assert.True(commitTime.Equal(ref.TagTime), ref.TagTime)
+}
+
+func TestVersionsBetween(t *testing.T) {
+	assert := assert.New(t)
+	dir, _ := ioutil.TempDir("", "example")
+	defer os.RemoveAll(dir)
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(err)
+	worktree, err := repo.Worktree()
+	assert.NoError(err)
+
+	for i, tag := range []string{"v1.0.0", "v1.1.0-rc.1", "v1.1.0", "nightly", "v1.2.0", "v2.0.0"} {
+		commit, err := worktree.Commit("commit", &git.CommitOptions{Author: &object.Signature{
+			Name:  "John Doe",
+			Email: "john@doe.org",
+			When:  time.Unix(int64(i), 0),
+		}})
+		assert.NoError(err)
+		_, err = repo.CreateTag(tag, commit, nil)
+		assert.NoError(err)
+	}
+
+	parse := func(s string) Version {
+		v, err := Parse(s)
+		assert.NoError(err)
+		return v
+	}
+	for _, test := range []struct {
+		from, to Version
+		tags     []string
+	}{
+		{Version{}, Version{}, []string{"v1.0.0", "v1.1.0-rc.1", "v1.1.0", "v1.2.0", "v2.0.0"}},
+		{parse("v1.0.0"), parse("v1.2.0"), []string{"v1.1.0-rc.1", "v1.1.0", "v1.2.0"}},
+		{parse("1.1.0-rc.1"), parse("1.2.0-rc.1"), []string{"v1.1.0"}},
+		{parse("v1.1.0"), Version{}, []string{"v1.2.0", "v2.0.0"}},
+		{Version{}, parse("v1.1.0"), []string{"v1.0.0", "v1.1.0-rc.1", "v1.1.0"}},
+		{parse("v2.0.0"), Version{}, nil},
+	} {
+		versions, err := VersionsBetween(dir, test.from, test.to)
+		assert.NoError(err)
+		var tags []string
+		for _, v := range versions {
+			tags = append(tags, v.String())
+		}
+		assert.Equal(test.tags, tags, "%s..%s", test.from, test.to)
+	}
+
+	_, err = VersionsBetween(filepath.Join(dir, "missing"), Version{}, Version{})
+	assert.Error(err)
+}
@@ -0,0 +1,95 @@
+package version
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var metaRefRe = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}|\{(commits|hash|branch|tagtime|env:([A-Za-z_][A-Za-z0-9_]*))\}`)
+
+// ExpandMeta replaces references of the form ${VAR} in the build metadata s with
+// the value of the environment variable VAR. A default value can be given with
+// ${VAR:-default}, which is used if VAR is not set or empty. Referencing a
+// variable that is not set and has no default is an error, as well as an
+// expanded result that is not valid build metadata.
+func ExpandMeta(s string) (string, error) {
+	return expandMeta(s, nil, nil)
+}
+
+// ExpandMetaTemplate is like ExpandMeta and additionally replaces the following
+// tokens with information about v:
+// * {commits} -> number of commits since the last tag
+// * {hash} -> commit hash abbreviated like the metadata, see WithAbbrev and WithGitHashPrefix
+// * {branch} -> branch name with illegal characters replaced by hyphens
+// * {tagtime} -> time of the last tag in UTC as described by CommitTimeFormat
+// * {env:VAR} -> value of the environment variable VAR, which must be set
+// E.g. {commits}.{hash}.{env:CI_RUN} yields 3.fcf2c8fa.1234.
+func ExpandMetaTemplate(s string, v Version, opts ...Option) (string, error) {
+	return expandMeta(s, &v, newOptions(opts))
+}
+
+func expandMeta(s string, v *Version, o *options) (string, error) {
+	var err error
+	fail := func(e error) string {
+		if err == nil {
+			err = e
+		}
+		return ""
+	}
+	result := metaRefRe.ReplaceAllStringFunc(s, func(ref string) string {
+		m := metaRefRe.FindStringSubmatch(ref)
+		switch {
+		case m[1] != "":
+			if value, ok := os.LookupEnv(m[1]); ok && (value != "" || m[2] == "") {
+				return value
+			}
+			if m[2] != "" {
+				return m[3]
+			}
+			return fail(fmt.Errorf("environment variable %s is not set", m[1]))
+		case v == nil:
+			return ref
+		case m[5] != "":
+			value, ok := os.LookupEnv(m[5])
+			if !ok {
+				return fail(fmt.Errorf("environment variable %s is not set", m[5]))
+			}
+			return value
+		case m[4] == "commits":
+			return strconv.Itoa(v.Commits)
+		case m[4] == "hash":
+			return o.metaHash(v.Hash)
+		case m[4] == "tagtime":
+			if v.TagTime.IsZero() {
+				return ""
+			}
+			return v.TagTime.UTC().Format(CommitTimeFormat)
+		default:
+			return sanitizeIdentifier(v.branch)
+		}
+	})
+	if err != nil {
+		return "", err
+	}
+	if err := validateMeta(result); err != nil {
+		return "", err
+	}
+	return result, nil
+}
+
+// validateMeta checks that every dot-separated identifier of the build metadata m
+// is non-empty and consists of alphanumerics and hyphens only.
+func validateMeta(m string) error {
+	if m == "" {
+		return nil
+	}
+	for _, id := range strings.Split(m, ".") {
+		if !identifierRe.MatchString(id) {
+			return fmt.Errorf("invalid build metadata identifier %q in %s", id, m)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package version
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandMeta(t *testing.T) {
+	assert := assert.New(t)
+	os.Setenv("GIT_SEMVER_TEST_BUILD", "42")
+	os.Setenv("GIT_SEMVER_TEST_RUNNER", "runner-7")
+	os.Setenv("GIT_SEMVER_TEST_EMPTY", "")
+	os.Unsetenv("GIT_SEMVER_TEST_UNSET")
+	defer os.Unsetenv("GIT_SEMVER_TEST_BUILD")
+	defer os.Unsetenv("GIT_SEMVER_TEST_RUNNER")
+	defer os.Unsetenv("GIT_SEMVER_TEST_EMPTY")
+
+	for _, test := range []struct {
+		in  string
+		out string
+	}{
+		{"custom", "custom"},
+		{"build.${GIT_SEMVER_TEST_BUILD}", "build.42"},
+		{"${GIT_SEMVER_TEST_BUILD}.${GIT_SEMVER_TEST_RUNNER}", "42.runner-7"},
+		{"build.${GIT_SEMVER_TEST_UNSET:-0}", "build.0"},
+		{"build.${GIT_SEMVER_TEST_EMPTY:-local}", "build.local"},
+		{"build.${GIT_SEMVER_TEST_BUILD:-0}", "build.42"},
+	} {
+		out, err := ExpandMeta(test.in)
+		assert.NoError(err)
+		assert.Equal(test.out, out)
+	}
+
+	_, err := ExpandMeta("build.${GIT_SEMVER_TEST_UNSET}")
+	assert.EqualError(err, "environment variable GIT_SEMVER_TEST_UNSET is not set")
+
+	_, err = ExpandMeta("build.${GIT_SEMVER_TEST_EMPTY}")
+	assert.EqualError(err, `invalid build metadata identifier "" in build.`)
+
+	_, err = ExpandMeta("build ${GIT_SEMVER_TEST_BUILD}")
+	assert.EqualError(err, `invalid build metadata identifier "build 42" in build 42`)
+}
+
+func TestExpandMetaTemplate(t *testing.T) {
+	assert := assert.New(t)
+	os.Setenv("GIT_SEMVER_TEST_RUN", "1234")
+	os.Setenv("GIT_SEMVER_TEST_EMPTY", "")
+	os.Unsetenv("GIT_SEMVER_TEST_UNSET")
+	defer os.Unsetenv("GIT_SEMVER_TEST_RUN")
+	defer os.Unsetenv("GIT_SEMVER_TEST_EMPTY")
+
+	v := Version{Major: 1, Minor: 2, Patch: 3, Commits: 3, Hash: "fcf2c8fa0aab8deb8a0a8b04d5d3d0c03ec0a8d9", branch: "feature/login"}
+	v.TagTime = time.Date(2024, 1, 15, 11, 30, 0, 0, time.FixedZone("CET", 3600))
+	for _, test := range []struct {
+		in  string
+		out string
+	}{
+		{"{commits}", "3"},
+		{"{hash}", "fcf2c8fa"},
+		{"{branch}", "feature-login"},
+		{"{tagtime}", "20240115103000"},
+		{"{env:GIT_SEMVER_TEST_RUN}", "1234"},
+		{"{commits}.{hash}.{env:GIT_SEMVER_TEST_RUN}", "3.fcf2c8fa.1234"},
+		{"build.${GIT_SEMVER_TEST_RUN}.{commits}", "build.1234.3"},
+	} {
+		out, err := ExpandMetaTemplate(test.in, v)
+		assert.NoError(err)
+		assert.Equal(test.out, out)
+	}
+
+	for _, test := range []struct {
+		opts []Option
+		out  string
+	}{
+		{[]Option{WithAbbrev(10)}, "fcf2c8fa0a"},
+		{[]Option{WithGitHashPrefix()}, "gfcf2c8fa"},
+		{[]Option{WithAbbrev(7), WithGitHashPrefix()}, "gfcf2c8f"},
+	} {
+		out, err := ExpandMetaTemplate("{hash}", v, test.opts...)
+		assert.NoError(err)
+		assert.Equal(test.out, out)
+	}
+
+	_, err := ExpandMetaTemplate("{env:GIT_SEMVER_TEST_UNSET}", v)
+	assert.EqualError(err, "environment variable GIT_SEMVER_TEST_UNSET is not set")
+
+	_, err = ExpandMetaTemplate("{commits}.{env:GIT_SEMVER_TEST_EMPTY}", v)
+	assert.EqualError(err, `invalid build metadata identifier "" in 3.`)
+
+	_, err = ExpandMetaTemplate("{commits}.{branch}", Version{})
+	assert.EqualError(err, `invalid build metadata identifier "" in 0.`)
+
+	_, err = ExpandMeta("{commits}")
+	assert.EqualError(err, `invalid build metadata identifier "{commits}" in {commits}`)
+}
@@ -0,0 +1,346 @@
+package version
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// Option configures how a version is derived from a repository.
+type Option func(*options)
+
+type options struct {
+	branchPreRelease bool
+	mainBranches     []string
+	stripPrefix      bool
+	strictTags       bool
+	noIncrement      bool
+	authorDate       bool
+	stableTagsOnly   bool
+	matchRegex       *regexp.Regexp
+	allowShortTags   bool
+	requireTags      bool
+	allTags          bool
+	appendHash       bool
+	cache            bool
+	skipNonSemver    bool
+	signKey          *openpgp.Entity
+	commitOffset     int
+	gitHashPrefix    bool
+	metaCommits      bool
+	abbrev           int
+	component        string
+	classifier       func(message string) BumpType
+	ignoreTags       map[string]bool
+	ignoreUntracked  bool
+	strictSemver     bool
+	dirtyMark        string
+	devSeparator     string
+	buildNumber      int
+	forcePreRelease  bool
+	workTree         string
+	totalCommits     bool
+}
+
+func newOptions(opts []Option) *options {
+	o := options{
+		mainBranches: []string{"main", "master"},
+		dirtyMark:    DefaultDirtyMark,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &o
+}
+
+// WithBranchPreRelease adds the sanitized name of the current branch to the
+// pre-release of commits that are not tagged, e.g. 1.2.4-feature-x.dev.5. Commits
+// on one of the given main branches (main and master by default) or on a detached
+// HEAD don't receive a branch label.
+func WithBranchPreRelease(mainBranches ...string) Option {
+	return func(o *options) {
+		o.branchPreRelease = true
+		if len(mainBranches) > 0 {
+			o.mainBranches = mainBranches
+		}
+	}
+}
+
+// WithMainBranch defines the branch releases are made from instead of main and
+// master, e.g. trunk or develop. Together with WithBranchPreRelease only commits
+// on other branches receive a branch label.
+func WithMainBranch(name string) Option {
+	return func(o *options) {
+		o.mainBranches = []string{name}
+	}
+}
+
+// WithoutPrefix removes the prefix that has been detected in the tag, so that
+// e.g. the tag v1.2.3 results in the version 1.2.3.
+func WithoutPrefix() Option {
+	return func(o *options) {
+		o.stripPrefix = true
+	}
+}
+
+// WithStrictTags makes the version derivation fail, if multiple tags with a
+// different version point to the commit the version is derived from. Without
+// this option the tag with the highest precedence is used.
+func WithStrictTags() Option {
+	return func(o *options) {
+		o.strictTags = true
+	}
+}
+
+// WithoutAutoIncrement disables the increment of the patch version for commits
+// after a tag, so that the third commit after 1.2.3 yields 1.2.3-dev.3 instead of
+// 1.2.4-dev.3. Note that this breaks the SemVer precedence, because 1.2.3-dev.3
+// is considered lower than the tagged version 1.2.3 it is based on.
+func WithoutAutoIncrement() Option {
+	return func(o *options) {
+		o.noIncrement = true
+	}
+}
+
+// WithAuthorDate uses the author date of HEAD as RepoHead.CommitTime instead of
+// the committer date. Both differ for commits that have been rebased or
+// cherry-picked.
+func WithAuthorDate() Option {
+	return func(o *options) {
+		o.authorDate = true
+	}
+}
+
+// WithStableTagsOnly ignores tags with a pre-release like v2.0.0-rc.1, so that the
+// version is derived from the last stable tag and the commits since then.
+func WithStableTagsOnly() Option {
+	return func(o *options) {
+		o.stableTagsOnly = true
+	}
+}
+
+// WithMatchRegex only considers tags whose name matches re, e.g. to select the
+// tags of a single component in a monorepo. If re has a capture group, only the
+// text matched by the first group is parsed as version and everything in front
+// of it is treated as prefix, e.g. ^api/(.+)$ matches the tag api/v1.2.3.
+func WithMatchRegex(re *regexp.Regexp) Option {
+	return func(o *options) {
+		o.matchRegex = re
+	}
+}
+
+// WithShortTags accepts tags with only a major and minor version like v1.2, which
+// are treated as if the patch version was 0. A pre-release or metadata may
+// follow as usual, e.g. v1.2-rc.1 results in 1.2.0-rc.1.
+func WithShortTags() Option {
+	return func(o *options) {
+		o.allowShortTags = true
+	}
+}
+
+// WithRequiredTags makes the version derivation fail with ErrNoTags if there is no
+// tag at all, instead of deriving the version from 0.0.0. This detects checkouts
+// in CI pipelines that don't include the tags.
+func WithRequiredTags() Option {
+	return func(o *options) {
+		o.requireTags = true
+	}
+}
+
+// WithAllTags also considers tags that have been fetched from remotes into
+// refs/remotes/<remote>/tags/, e.g. in fork-based workflows. If a local and a
+// remote tag have the same name, the local tag is used.
+func WithAllTags() Option {
+	return func(o *options) {
+		o.allTags = true
+	}
+}
+
+// WithAppendHash appends the abbreviated commit hash to the metadata of a tag like
+// v1.2.3+build.7 for commits after the tag, e.g. 1.2.4-dev.3+build.7.fcf2c8fa. By
+// default the metadata of the tag is kept as is.
+func WithAppendHash() Option {
+	return func(o *options) {
+		o.appendHash = true
+	}
+}
+
+// WithCache stores the result of GitDescribe in the file git-semver-cache inside
+// the git directory and reuses it as long as HEAD, the tags and the options are
+// unchanged. Repositories that are not stored on the file system are never
+// cached.
+func WithCache() Option {
+	return func(o *options) {
+		o.cache = true
+	}
+}
+
+// WithSkipNonSemverTags ignores tags that are no valid version like nightly-20240101,
+// so that the version is derived from the last tag that is a valid version.
+func WithSkipNonSemverTags() Option {
+	return func(o *options) {
+		o.skipNonSemver = true
+	}
+}
+
+// WithSigningKey signs the tags created by CreateTag with the given OpenPGP key,
+// which has to contain a decrypted private key.
+func WithSigningKey(entity *openpgp.Entity) Option {
+	return func(o *options) {
+		o.signKey = entity
+	}
+}
+
+// WithCommitOffset adds n to the number of commits since the last tag, e.g. to
+// continue the build numbers of a former versioning scheme. Tagged commits are
+// not affected and a negative result is treated as 0.
+func WithCommitOffset(n int) Option {
+	return func(o *options) {
+		o.commitOffset = n
+	}
+}
+
+// WithBuildNumber uses n instead of the number of commits since the last tag for
+// the dev.<n> pre-release and the metadata, e.g. the authoritative build number
+// of a CI system. Unlike WithCommitOffset, tagged commits are not affected unless
+// WithForcePreRelease is used. Values less than 1 are ignored.
+func WithBuildNumber(n int) Option {
+	return func(o *options) {
+		o.buildNumber = n
+	}
+}
+
+// WithForcePreRelease applies the build number of WithBuildNumber to tagged
+// commits as well, so that they get a dev.<n> pre-release like untagged commits.
+func WithForcePreRelease() Option {
+	return func(o *options) {
+		o.forcePreRelease = true
+	}
+}
+
+// WithGitHashPrefix prepends a g to the commit hash in the build metadata like
+// git describe does, e.g. 1.2.4-dev.3+gfcf2c8fa.
+func WithGitHashPrefix() Option {
+	return func(o *options) {
+		o.gitHashPrefix = true
+	}
+}
+
+// WithMetaCommits uses the number of commits since the last tag instead of the
+// commit hash as build metadata, e.g. 1.2.4-dev.3+3, for systems that only accept
+// numeric metadata. The metadata of a tag is kept as is.
+func WithMetaCommits() Option {
+	return func(o *options) {
+		o.metaCommits = true
+	}
+}
+
+// WithAbbrev abbreviates the commit hash in the build metadata to n characters
+// instead of DefaultAbbrev. Like git, n is raised to at least 4 and limited to
+// the 40 characters of a full hash.
+func WithAbbrev(n int) Option {
+	return func(o *options) {
+		switch {
+		case n < 4:
+			n = 4
+		case n > 40:
+			n = 40
+		}
+		o.abbrev = n
+	}
+}
+
+// WithComponent only considers the tags of a component in a monorepo, i.e. tags
+// like api/v1.2.3 for the component api. The component path is part of the
+// prefix, also if there is no tag yet, so that e.g. api/0.0.1-dev.3 results.
+func WithComponent(name string) Option {
+	return func(o *options) {
+		o.component = strings.TrimSuffix(name, "/")
+	}
+}
+
+// WithBumpClassifier replaces ConventionalBump in RecommendBump and NextVersion
+// with a custom classification of commit messages, e.g. for Jira-style tokens
+// like [MAJOR]. Commits classified as Invalid don't require a release.
+func WithBumpClassifier(classify func(message string) BumpType) Option {
+	return func(o *options) {
+		o.classifier = classify
+	}
+}
+
+func (o *options) isMainBranch(branch string) bool {
+	for _, b := range o.mainBranches {
+		if b == branch {
+			return true
+		}
+	}
+	return false
+}
+
+// WithIgnoreTags excludes the tags with the given names, e.g. a botched release
+// that can't be deleted anymore. A name matches a tag exactly or the tag without
+// its prefix, so that 9.9.9 also ignores the tag v9.9.9.
+func WithIgnoreTags(names ...string) Option {
+	return func(o *options) {
+		if o.ignoreTags == nil {
+			o.ignoreTags = make(map[string]bool)
+		}
+		for _, name := range names {
+			o.ignoreTags[name] = true
+		}
+	}
+}
+
+// WithIgnoreUntracked considers only changes of tracked files in DirtyFiles, so
+// that e.g. untracked build artifacts don't make the worktree dirty.
+func WithIgnoreUntracked() Option {
+	return func(o *options) {
+		o.ignoreUntracked = true
+	}
+}
+
+// WithStrictSemver treats tags with a prefix like v1.2.3 as invalid, since the
+// prefix is not part of the SemVer spec. NewFromRepo fails if the last tag has a
+// prefix and ValidateTags reports such tags.
+func WithStrictSemver() Option {
+	return func(o *options) {
+		o.strictSemver = true
+	}
+}
+
+// WithDirtyMark sets the suffix that NewFromDescribe recognizes as dirty mark
+// instead of DefaultDirtyMark, like git describe --dirty=<mark>.
+func WithDirtyMark(mark string) Option {
+	return func(o *options) {
+		o.dirtyMark = mark
+	}
+}
+
+// WithDevSeparator separates the pre-release of the tag from the dev.<n> part with
+// sep instead of DefaultDevSeparator, e.g. rc.1-dev.3 for a hyphen. The separator
+// must be a dot or consist of alphanumerics and hyphens, so that the result is a
+// valid pre-release.
+func WithDevSeparator(sep string) Option {
+	return func(o *options) {
+		o.devSeparator = sep
+	}
+}
+
+// WithWorkTree opens the repository with path as its git directory and the
+// worktree at dir, like the environment variables GIT_DIR and GIT_WORK_TREE of
+// git, e.g. for a git directory that is stored apart from the worktree.
+func WithWorkTree(dir string) Option {
+	return func(o *options) {
+		o.workTree = dir
+	}
+}
+
+// WithTotalCommits counts all commits reachable from HEAD as RepoHead.TotalCommits.
+// Otherwise GitDescribe stops at the last tag, since walking the whole history
+// is slow for large repositories.
+func WithTotalCommits() Option {
+	return func(o *options) {
+		o.totalCommits = true
+	}
+}
@@ -0,0 +1,37 @@
+package version
+
+import "math"
+
+const (
+	rankFieldMax = 999
+	// rankMajorMax leaves room for the digits mmmpppr of the largest minor and
+	// patch version of a release, i.e. 9999991.
+	rankMajorMax = (math.MaxUint64 - 9_999_991) / 10_000_000
+)
+
+// Rank encodes the core version as an integer whose order matches the precedence
+// of versions, e.g. to store it in a database column. The decimal digits of the
+// rank are laid out as MMMmmmpppr, where M is the major version, m the minor and
+// p the patch version with three digits each and r is 1 for releases and 0 for
+// pre-releases, so that 1.2.3 has the rank 10020031 and 1.2.3-rc.1 the rank
+// 10020030. Minor and patch versions above 999 and major versions that would
+// overflow are clamped, negative components are treated as 0. Pre-releases of the
+// same core version share the same rank.
+func (v Version) Rank() uint64 {
+	clamp := func(i int, max uint64) uint64 {
+		switch {
+		case i < 0:
+			return 0
+		case uint64(i) > max:
+			return max
+		}
+		return uint64(i)
+	}
+	r := clamp(v.Major, rankMajorMax)*1000 + clamp(v.Minor, rankFieldMax)
+	r = r*1000 + clamp(v.effectivePatch(), rankFieldMax)
+	r *= 10
+	if v.PreRelease() == "" {
+		r++
+	}
+	return r
+}
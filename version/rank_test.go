@@ -0,0 +1,62 @@
+package version
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRank(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(uint64(10020031), Version{Major: 1, Minor: 2, Patch: 3}.Rank())
+	assert.Equal(uint64(10020030), Version{Major: 1, Minor: 2, Patch: 3, preRelease: "rc.1"}.Rank())
+	assert.Equal(uint64(10020040), Version{Major: 1, Minor: 2, Patch: 3, Commits: 2}.Rank())
+	assert.Equal(uint64(9999991), Version{Minor: 1000, Patch: 1000}.Rank())
+	assert.Equal(uint64(1), Version{Major: -1}.Rank())
+
+	max := Version{Major: rankMajorMax, Minor: 999, Patch: 999}
+	assert.Equal(uint64(rankMajorMax*10_000_000+9_999_991), max.Rank())
+	assert.Equal(max.Rank(), Version{Major: math.MaxInt64, Minor: 999, Patch: 999}.Rank())
+	for _, pair := range [][2]Version{
+		{{Major: rankMajorMax - 1, Minor: 999, Patch: 999}, {Major: rankMajorMax}},
+		{{Major: rankMajorMax}, {Major: rankMajorMax, Patch: 1}},
+		{{Major: rankMajorMax, Minor: 998, Patch: 999}, max},
+		{{Major: rankMajorMax, Minor: 999, Patch: 999, preRelease: "rc.1"}, max},
+	} {
+		assert.Less(pair[0].Rank(), pair[1].Rank(), "%s %s", pair[0], pair[1])
+	}
+
+	var versions []Version
+	for _, s := range []string{
+		"0.0.1-rc.1",
+		"0.0.1",
+		"0.1.0",
+		"0.1.1",
+		"0.999.999",
+		"1.0.0-alpha",
+		"1.0.0",
+		"1.2.3-rc.1",
+		"1.2.3",
+		"1.10.0",
+		"2.0.0",
+		"10.0.0",
+	} {
+		v, err := Parse(s)
+		assert.NoError(err)
+		versions = append(versions, v)
+	}
+	for i := range versions {
+		for j := range versions {
+			c := versions[i].Compare(versions[j])
+			switch {
+			case c < 0:
+				assert.Less(versions[i].Rank(), versions[j].Rank(), "%s %s", versions[i], versions[j])
+			case c > 0:
+				assert.Greater(versions[i].Rank(), versions[j].Rank(), "%s %s", versions[i], versions[j])
+			default:
+				assert.Equal(versions[i].Rank(), versions[j].Rank(), "%s %s", versions[i], versions[j])
+			}
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package version
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/go-git/go-git/v5"
+)
+
+// NewFromSubmodule calculates the version of the commit that is checked out in
+// the submodule with the given name of the repository at repoPath like
+// NewFromRepo does. An error wrapping git.ErrSubmoduleNotInitialized is returned
+// if the submodule hasn't been cloned yet.
+func NewFromSubmodule(repoPath, submoduleName string, opts ...Option) (Version, error) {
+	o := newOptions(opts)
+	repo, err := openRepo(repoPath, o)
+	if err != nil {
+		return Version{}, fmt.Errorf("failed to open repo: %w", err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return Version{}, fmt.Errorf("failed to open worktree: %w", err)
+	}
+	submodule, err := worktree.Submodule(submoduleName)
+	if err != nil {
+		return Version{}, fmt.Errorf("failed to find submodule %s: %w", submoduleName, err)
+	}
+	root := repoPath
+	if o.workTree != "" {
+		root = o.workTree
+	}
+	// the submodule has a worktree of its own
+	opts = append(opts, WithWorkTree(""))
+	path := filepath.Join(root, filepath.FromSlash(submodule.Config().Path))
+	if _, err := openRepo(path, newOptions(opts)); errors.Is(err, git.ErrRepositoryNotExists) {
+		return Version{}, fmt.Errorf("failed to open submodule %s, run git submodule update --init: %w", submoduleName, git.ErrSubmoduleNotInitialized)
+	}
+	return NewFromRepo(path, opts...)
+}
@@ -0,0 +1,51 @@
+package version
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewFromSubmodule(t *testing.T) {
+	assert := assert.New(t)
+	dir, _ := ioutil.TempDir("", "example")
+	defer os.RemoveAll(dir)
+	commit := func(path, tag string) {
+		repo, err := git.PlainInit(path, false)
+		assert.NoError(err)
+		worktree, err := repo.Worktree()
+		assert.NoError(err)
+		hash, err := worktree.Commit("commit", &git.CommitOptions{Author: &object.Signature{
+			Name:  "John Doe",
+			Email: "john@doe.org",
+			When:  time.Now(),
+		}})
+		assert.NoError(err)
+		_, err = repo.CreateTag(tag, hash, nil)
+		assert.NoError(err)
+	}
+	commit(dir, "v1.0.0")
+	commit(filepath.Join(dir, "lib"), "v2.3.4")
+	gitmodules := "[submodule \"lib\"]\n\tpath = lib\n\turl = https://example.com/lib.git\n" +
+		"[submodule \"docs\"]\n\tpath = docs\n\turl = https://example.com/docs.git\n"
+	assert.NoError(ioutil.WriteFile(filepath.Join(dir, ".gitmodules"), []byte(gitmodules), 0644))
+
+	v, err := NewFromSubmodule(dir, "lib")
+	assert.NoError(err)
+	assert.Equal("v2.3.4", v.String())
+	v, err = NewFromSubmodule(dir, "lib", WithoutPrefix())
+	assert.NoError(err)
+	assert.Equal("2.3.4", v.String())
+
+	_, err = NewFromSubmodule(dir, "docs")
+	assert.True(errors.Is(err, git.ErrSubmoduleNotInitialized))
+	_, err = NewFromSubmodule(dir, "missing")
+	assert.True(errors.Is(err, git.ErrSubmoduleNotFound))
+}
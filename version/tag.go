@@ -0,0 +1,100 @@
+package version
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// DirtyFiles returns the sorted paths of all files in the worktree of the
+// repository at path that are modified, staged or untracked. Untracked files are
+// left out with WithIgnoreUntracked. The worktree is clean if the list is empty.
+func DirtyFiles(path string, opts ...Option) ([]string, error) {
+	o := newOptions(opts)
+	repo, err := openRepo(path, o)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repo: %w", err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open worktree: %w", err)
+	}
+	status, err := worktree.Status()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve worktree status: %w", err)
+	}
+	var files []string
+	for file, s := range status {
+		if o.ignoreUntracked && s.Worktree == git.Untracked {
+			continue
+		}
+		if s.Staging != git.Unmodified || s.Worktree != git.Unmodified {
+			files = append(files, file)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// ErrNoSigningKey is returned by CreateTag if the signing key passed with
+// WithSigningKey has no usable private key.
+var ErrNoSigningKey = errors.New("no decrypted private key available for signing")
+
+// CreateTag creates an annotated tag with the given name and message at HEAD of
+// the repository at path. The tagger is taken from the user section of the repo
+// config, GIT_COMMITTER_NAME and GIT_COMMITTER_EMAIL or defaults to git-semver.
+// The tag is signed if WithSigningKey is used.
+func CreateTag(path, name, message string, opts ...Option) error {
+	o := newOptions(opts)
+	if o.signKey != nil && (o.signKey.PrivateKey == nil || o.signKey.PrivateKey.Encrypted) {
+		return ErrNoSigningKey
+	}
+	repo, err := openRepo(path, o)
+	if err != nil {
+		return fmt.Errorf("failed to open repo: %w", err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return fmt.Errorf("failed to retrieve repo head: %w", err)
+	}
+	tagger, err := taggerSignature(repo)
+	if err != nil {
+		return err
+	}
+	_, err = repo.CreateTag(name, head.Hash(), &git.CreateTagOptions{
+		Tagger:  tagger,
+		Message: message,
+		SignKey: o.signKey,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create tag %s: %w", name, err)
+	}
+	return nil
+}
+
+func taggerSignature(repo *git.Repository) (*object.Signature, error) {
+	cfg, err := repo.Config()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read repo config: %w", err)
+	}
+	sig := object.Signature{
+		Name:  cfg.User.Name,
+		Email: cfg.User.Email,
+		When:  time.Now(),
+	}
+	if sig.Name == "" {
+		sig.Name = os.Getenv("GIT_COMMITTER_NAME")
+	}
+	if sig.Email == "" {
+		sig.Email = os.Getenv("GIT_COMMITTER_EMAIL")
+	}
+	if sig.Name == "" {
+		sig.Name = "git-semver"
+	}
+	return &sig, nil
+}
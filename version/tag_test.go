@@ -0,0 +1,159 @@
+package version
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+)
+
+func TestDirtyFilesIgnoreUntracked(t *testing.T) {
+	assert := assert.New(t)
+	dir, _ := ioutil.TempDir("", "example")
+	defer os.RemoveAll(dir)
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(err)
+	worktree, err := repo.Worktree()
+	assert.NoError(err)
+	assert.NoError(ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte("package main"), 0644))
+	_, err = worktree.Add("main.go")
+	assert.NoError(err)
+	_, err = worktree.Commit("commit", &git.CommitOptions{Author: &object.Signature{
+		Name:  "John Doe",
+		Email: "john@doe.org",
+		When:  time.Now(),
+	}})
+	assert.NoError(err)
+
+	assert.NoError(ioutil.WriteFile(filepath.Join(dir, "build.log"), []byte("ok"), 0644))
+	files, err := DirtyFiles(dir)
+	assert.NoError(err)
+	assert.Equal([]string{"build.log"}, files)
+	files, err = DirtyFiles(dir, WithIgnoreUntracked())
+	assert.NoError(err)
+	assert.Empty(files)
+
+	assert.NoError(ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte("package foo"), 0644))
+	files, err = DirtyFiles(dir, WithIgnoreUntracked())
+	assert.NoError(err)
+	assert.Equal([]string{"main.go"}, files)
+}
+
+func TestDirtyFilesWorkTree(t *testing.T) {
+	assert := assert.New(t)
+	dir, _ := ioutil.TempDir("", "example")
+	defer os.RemoveAll(dir)
+	workTree, _ := ioutil.TempDir("", "example")
+	defer os.RemoveAll(workTree)
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(err)
+	worktree, err := repo.Worktree()
+	assert.NoError(err)
+	assert.NoError(ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte("package main"), 0644))
+	_, err = worktree.Add("main.go")
+	assert.NoError(err)
+	_, err = worktree.Commit("commit", &git.CommitOptions{Author: &object.Signature{
+		Name:  "John Doe",
+		Email: "john@doe.org",
+		When:  time.Now(),
+	}})
+	assert.NoError(err)
+
+	gitDir := filepath.Join(dir, ".git")
+	files, err := DirtyFiles(gitDir, WithWorkTree(workTree))
+	assert.NoError(err)
+	assert.Equal([]string{"main.go"}, files)
+
+	assert.NoError(ioutil.WriteFile(filepath.Join(workTree, "main.go"), []byte("package main"), 0644))
+	files, err = DirtyFiles(gitDir, WithWorkTree(workTree))
+	assert.NoError(err)
+	assert.Empty(files)
+
+	v, err := NewFromRepo(gitDir, WithWorkTree(workTree))
+	assert.NoError(err)
+	assert.Equal(1, v.Commits)
+}
+
+func TestCreateTag(t *testing.T) {
+	assert := assert.New(t)
+	dir, _ := ioutil.TempDir("", "example")
+	defer os.RemoveAll(dir)
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(err)
+	worktree, err := repo.Worktree()
+	assert.NoError(err)
+	head, err := worktree.Commit("commit", &git.CommitOptions{Author: &object.Signature{
+		Name:  "John Doe",
+		Email: "john@doe.org",
+		When:  time.Now(),
+	}})
+	assert.NoError(err)
+
+	files, err := DirtyFiles(dir)
+	assert.NoError(err)
+	assert.Empty(files)
+	assert.NoError(ioutil.WriteFile(filepath.Join(dir, "build.log"), []byte("ok"), 0644))
+	files, err = DirtyFiles(dir)
+	assert.NoError(err)
+	assert.Equal([]string{"build.log"}, files)
+
+	assert.NoError(CreateTag(dir, "v1.0.0", "Release v1.0.0"))
+	ref, err := repo.Tag("v1.0.0")
+	assert.NoError(err)
+	tag, err := repo.TagObject(ref.Hash())
+	assert.NoError(err)
+	assert.Equal("Release v1.0.0\n", tag.Message)
+	assert.Equal(head, tag.Target)
+	assert.NotEmpty(tag.Tagger.Name)
+
+	v, err := NewFromRepo(dir)
+	assert.NoError(err)
+	assert.Equal("v1.0.0", v.String())
+
+	assert.Error(CreateTag(dir, "v1.0.0", "Release v1.0.0"))
+}
+
+func TestCreateSignedTag(t *testing.T) {
+	assert := assert.New(t)
+	dir, _ := ioutil.TempDir("", "example")
+	defer os.RemoveAll(dir)
+	repo, err := git.PlainInit(dir, false)
+	assert.NoError(err)
+	worktree, err := repo.Worktree()
+	assert.NoError(err)
+	_, err = worktree.Commit("commit", &git.CommitOptions{Author: &object.Signature{
+		Name:  "John Doe",
+		Email: "john@doe.org",
+		When:  time.Now(),
+	}})
+	assert.NoError(err)
+
+	entity, err := openpgp.NewEntity("John Doe", "", "john@doe.org", nil)
+	assert.NoError(err)
+	assert.NoError(CreateTag(dir, "v1.0.0", "Release v1.0.0", WithSigningKey(entity)))
+	ref, err := repo.Tag("v1.0.0")
+	assert.NoError(err)
+	tag, err := repo.TagObject(ref.Hash())
+	assert.NoError(err)
+	assert.NotEmpty(tag.PGPSignature)
+
+	var keyRing bytes.Buffer
+	w, err := armor.Encode(&keyRing, openpgp.PublicKeyType, nil)
+	assert.NoError(err)
+	assert.NoError(entity.Serialize(w))
+	assert.NoError(w.Close())
+	signer, err := tag.Verify(keyRing.String())
+	assert.NoError(err)
+	assert.Equal(entity.PrimaryKey.KeyId, signer.PrimaryKey.KeyId)
+
+	public := &openpgp.Entity{PrimaryKey: entity.PrimaryKey, Identities: entity.Identities}
+	assert.Equal(ErrNoSigningKey, CreateTag(dir, "v1.0.1", "Release v1.0.1", WithSigningKey(public)))
+}
@@ -0,0 +1,30 @@
+package version
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// templateFuncs are the functions that are available in templates executed by
+// Version.Template in addition to the predefined functions of text/template.
+var templateFuncs = template.FuncMap{
+	"core": Version.Core,
+}
+
+// Template renders the Go text/template tmpl with the version as data, e.g.
+// {{.Major}}.{{.Minor}} ({{.Commits}} commits). All fields and methods of Version
+// can be used, like .PreRelease, .Hash or .Describe. Note that .Patch is the patch
+// version of the tag, whereas {{core .}} renders major.minor.patch including the
+// prefix and the incremented patch version for commits after the tag.
+func (v Version) Template(tmpl string) (string, error) {
+	t, err := template.New("version").Funcs(templateFuncs).Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template: %w", err)
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, v); err != nil {
+		return "", fmt.Errorf("failed to execute template: %w", err)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,31 @@
+package version
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplate(t *testing.T) {
+	assert := assert.New(t)
+	v := Version{Prefix: "v", Major: 1, Minor: 2, Patch: 3, preRelease: "rc.1", Commits: 4, Meta: "fcf2c8fa", Hash: "fcf2c8fa8b6e4e0c9e1d"}
+	for _, test := range []struct {
+		tmpl string
+		s    string
+	}{
+		{"{{.Major}}.{{.Minor}} ({{.Commits}} commits)", "1.2 (4 commits)"},
+		{"{{core .}}-{{.PreRelease}}", "v1.2.3-rc.1.dev.4"},
+		{"{{.Hash}} {{.PEP440}}", "fcf2c8fa8b6e4e0c9e1d 1.2.3rc2.dev4+fcf2c8fa"},
+		{"{{with .WithMeta \"\"}}{{.String}}{{end}}", "v1.2.3-rc.1.dev.4"},
+		{"{{if .Commits}}dev{{else}}release{{end}}", "dev"},
+	} {
+		s, err := v.Template(test.tmpl)
+		assert.NoError(err)
+		assert.Equal(test.s, s, test.tmpl)
+	}
+
+	_, err := v.Template("{{.Major")
+	assert.Error(err)
+	_, err = v.Template("{{.Unknown}}")
+	assert.Error(err)
+}
@@ -2,10 +2,15 @@ package version
 
 import (
 	"fmt"
+	"io"
 	"regexp"
+	"sort"
 	"strconv"
 	"errors"
 	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
 )
 
 // DefaultPrefix that is recognized and ignored by the parser
@@ -23,18 +28,58 @@ const (
 
 type buffer []byte
 
-func (b *buffer) AppendInt(i int, sep byte) {
-	b.AppendString(strconv.FormatInt(int64(i), 10), sep)
+func (b *buffer) AppendInt(i int, sep string) {
+	if len(*b) > 0 {
+		*b = append(*b, sep...)
+	}
+	*b = strconv.AppendInt(*b, int64(i), 10)
 }
 
-func (b *buffer) AppendString(s string, sep byte) {
+func (b *buffer) AppendString(s string, sep string) {
 	if len(s) > 0 && len(*b) > 0 {
-		*b = append(*b, sep)
+		*b = append(*b, sep...)
 	}
 	*b = append(*b, s...)
 }
 
-// Version holds the parsed components of git describe
+const formatVerbs = "xyzpPrmdHn"
+
+// CommitTimeFormat is the layout of the commit time in formatted versions. It
+// only consists of digits, so that it can be used in the build metadata.
+const CommitTimeFormat = "20060102150405"
+
+// formatToken is a format verb together with the literal separator that precedes
+// it in the format string.
+type formatToken struct {
+	sep  string
+	verb byte
+}
+
+// parseFormat splits a format string into its verbs and separators. Any character
+// that is not alphanumeric can be used as separator.
+func parseFormat(format string) ([]formatToken, error) {
+	var tokens []formatToken
+	start := 0
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		switch {
+		case strings.IndexByte(formatVerbs, c) >= 0:
+			tokens = append(tokens, formatToken{sep: format[start:i], verb: c})
+			start = i + 1
+		case '0' <= c && c <= '9', 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z':
+			return nil, fmt.Errorf("invalid format: %s", format)
+		}
+	}
+	if len(tokens) == 0 || start < len(format) {
+		return nil, fmt.Errorf("invalid format: %s", format)
+	}
+	return tokens, nil
+}
+
+// Version holds the parsed components of git describe. Commits is the number of
+// commits since the last tag and must not be negative, negative values are
+// treated as 0. TagTime is the time of the last tag, see RepoHead. Dirty is set
+// if the worktree had local changes, see NewFromDescribe.
 type Version struct {
 	Prefix     string
 	Major      int
@@ -43,7 +88,15 @@ type Version struct {
 	preRelease string
 	Commits    int
 	Meta       string
+	Hash       string
+	CommitTime time.Time
+	TagTime    time.Time
+	Dirty      bool
 	releaseCandidate int
+	tag        string
+	branch     string
+	noIncrement bool
+	devSeparator string
 }
 
 // Format returns a string representation of the version including the parts
@@ -51,51 +104,33 @@ type Version struct {
 // * x -> major version
 // * y -> minor version
 // * z -> patch version
-// * p -> pre-release
+// * p -> pre-release including the dev.<n> suffix for commits after the tag
+// * P -> pre-release of the tag without dev.<n> suffix, see PreReleaseRaw
 // * m -> metadata
 // * r -> release-candidate
-// x, y and z are separated by a dot. p is seprated by a hyphen and m by a plus sing.
-// E.g.: x.y.z-p+m or x.y
+// * d -> commit time in UTC as described by CommitTimeFormat
+// * H -> full commit hash
+// * n -> number of commits since the tag, 0 for a tagged commit
+// The characters in between the components are used as separators, e.g.: x.y.z-p+m,
+// x.y or x_y_z. A separator is omitted if the component following it is empty, so
+// that x.y.z-p+m yields 1.2.3 for a version without pre-release and metadata.
+// The prefix is prepended exactly once, regardless of the components.
 func (v Version) Format(format string) (string, error) {
-	re := regexp.MustCompile(
-		`(?P<major>x)(?P<minor>\.y)?(?P<patch>\.z)?(?P<pre>-p)?(?P<release_candidate>-r)?(?P<meta>\+m)?`)
-
-	matches := re.FindStringSubmatch(format)
-	if matches == nil {
-		return "", fmt.Errorf("invalid format: %s", format)
+	f, err := NewFormatter(format)
+	if err != nil {
+		return "", err
 	}
+	return f.Format(v)
+}
 
-	var buf buffer
-
-	names := re.SubexpNames()
-	for i := 0; i < len(matches); i++ {
-		if len(matches[i]) == 0 {
-			continue
-		}
-		switch names[i] {
-		case "major":
-			buf.AppendInt(v.Major, '.')
-		case "minor":
-			buf.AppendInt(v.Minor, '.')
-		case "patch":
-			patch := v.Patch
-			if v.Commits > 0 && v.preRelease == "" {
-				patch++
-			}
-			buf.AppendInt(patch, '.')
-		case "pre":
-			buf.AppendString(v.PreRelease(), '-')
-		case "release_candidate":
-			releaseCandidate, err := v.ReleaseCandidate()
-			if err != nil {
-				return "", err
-			}
-			buf.AppendString(releaseCandidate, '-')
-		case "meta":
-			buf.AppendString(v.Meta, '+')
-		}
+// FormatInto writes the version formatted as described for Format to w. Use a
+// Formatter to format many versions with the same format.
+func (v Version) FormatInto(w io.Writer, format string) error {
+	f, err := NewFormatter(format)
+	if err != nil {
+		return err
 	}
-	return v.Prefix + string(buf), nil
+	return f.FormatInto(w, v)
 }
 
 func (v Version) String() string {
@@ -106,28 +141,125 @@ func (v Version) String() string {
 	return result
 }
 
+// NoMeta returns the version formatted with NoMetaFormat, i.e. without build
+// metadata.
+func (v Version) NoMeta() string {
+	result, _ := v.Format(NoMetaFormat)
+	return result
+}
+
+// Core returns the version formatted with NoPreFormat, i.e. major.minor.patch with
+// the prefix. Like all formats it applies the implicit increment of the patch
+// version for commits after a tag, so that 1.2.4 is returned for 1.2.4-dev.3.
+func (v Version) Core() string {
+	result, _ := v.Format(NoPreFormat)
+	return result
+}
+
+// effectivePatch returns the patch version, which is incremented in case there
+// are commits since the last tag, unless the tag has a pre-release itself or the
+// increment has been disabled with WithoutAutoIncrement.
+func (v Version) effectivePatch() int {
+	if v.Commits > 0 && v.preRelease == "" && !v.noIncrement {
+		return v.Patch + 1
+	}
+	return v.Patch
+}
+
 // PreRelease formats the pre-release version depending on the number n of commits since the
-// last tag. If n is zero it returns the parsed pre-release version. If n is greater than zero
-// it will append the string "dev.<n>" to the pre-release version.
+// last tag. If n is zero (or negative) it returns the parsed pre-release version. If n is
+// greater than zero it will append the string "dev.<n>" to the pre-release version,
+// preceded by the branch label if there is one. The parsed pre-release is separated by
+// a dot or the separator given with WithDevSeparator.
 func (v Version) PreRelease() string {
-	if v.Commits == 0 {
+	if v.Commits <= 0 {
 		return v.preRelease
 	}
+	var parts []string
+	if v.branch != "" {
+		parts = append(parts, v.branch)
+	}
+	parts = append(parts, fmt.Sprintf("dev.%d", v.Commits))
+	dev := strings.Join(parts, ".")
 	if v.preRelease == "" {
-		return fmt.Sprintf("dev.%d", v.Commits)
+		return dev
+	}
+	sep := v.devSeparator
+	if sep == "" {
+		sep = DefaultDevSeparator
+	}
+	return v.preRelease + sep + dev
+}
+
+// DefaultDevSeparator separates the pre-release of the tag from the dev.<n> part
+// that is appended for commits after the tag, e.g. rc.1.dev.3.
+const DefaultDevSeparator = "."
+
+var devSeparatorRe = regexp.MustCompile(`^(\.|[0-9A-Za-z-]+)$`)
+
+// validateDevSeparator checks that the separator keeps the pre-release a valid
+// sequence of identifiers, i.e. that it is a single dot or extends the last
+// identifier with alphanumerics and hyphens only.
+func validateDevSeparator(sep string) error {
+	if !devSeparatorRe.MatchString(sep) {
+		return fmt.Errorf("invalid dev separator %q: must be a dot or consist of alphanumerics and hyphens", sep)
 	}
-	return fmt.Sprintf("%s.dev.%d", v.preRelease, v.Commits)
+	return nil
+}
+
+// PreReleaseRaw returns the pre-release as parsed from the tag, e.g. rc.1, without
+// the dev.<n> suffix and branch label that PreRelease adds for commits after the
+// tag.
+func (v Version) PreReleaseRaw() string {
+	return v.preRelease
+}
+
+// Normalize returns a copy of the version with the prefix, the pre-release
+// including the branch label and the metadata in lower case, e.g. V1.2.3-RC.1
+// becomes v1.2.3-rc.1. Note that SemVer identifiers are case-sensitive, so that
+// the precedence can change: RC.1 sorts before beta.1, but rc.1 after it.
+func (v Version) Normalize() Version {
+	v.Prefix = strings.ToLower(v.Prefix)
+	v.preRelease = strings.ToLower(v.preRelease)
+	v.branch = strings.ToLower(v.branch)
+	v.Meta = strings.ToLower(v.Meta)
+	return v
 }
 
+// PreReleaseIdentifier is a dot-separated identifier of a pre-release. Numeric
+// identifiers consist of digits only and are compared numerically by the SemVer
+// precedence rules, all others are compared lexically.
+type PreReleaseIdentifier struct {
+	Value   string
+	Numeric bool
+}
+
+// PreReleaseParts returns the dot-separated identifiers of the pre-release as
+// returned by PreRelease, e.g. rc and 1 for rc.1. The result is empty for a
+// version without pre-release.
+func (v Version) PreReleaseParts() []PreReleaseIdentifier {
+	p := v.PreRelease()
+	if p == "" {
+		return nil
+	}
+	ids := strings.Split(p, ".")
+	parts := make([]PreReleaseIdentifier, len(ids))
+	for i, id := range ids {
+		parts[i] = PreReleaseIdentifier{Value: id, Numeric: isNumeric(id)}
+	}
+	return parts
+}
+
+var releaseCandidateRe = regexp.MustCompile(`^([a-z]+(?:-[a-z]+)*)\.([0-9]+)$`)
+
 func (v Version) ReleaseCandidate() (string, error) {
 	if v.preRelease == "" {
 		return "rc.1", nil
 	}
-	re := regexp.MustCompile(`^([a-z]+)\.([0-9]+)$`)
-	if !re.MatchString(v.preRelease) {
+	if !releaseCandidateRe.MatchString(v.preRelease) {
 		return "", errors.New("pre-release does not match the release-candidate format (rc.1, other.1)")
 	}
-	st := re.FindStringSubmatch(v.preRelease)
+	st := releaseCandidateRe.FindStringSubmatch(v.preRelease)
 	if len(st) != 3 {
 		return "", errors.New("pre-release does not match the release-candidate format (rc.1, other.1)")
 	}
@@ -141,25 +273,236 @@ func (v Version) ReleaseCandidate() (string, error) {
 	return fmt.Sprintf("%s.%d", st[1], i), nil
 }
 
-func NewFromHead(head *RepoHead) (Version, error) {
-	v := Version{Commits: head.CommitsSinceTag}
-	if strings.HasPrefix(head.LastTag, DefaultPrefix) {
-		v.Prefix = DefaultPrefix
+// IsZero reports whether v is the zero value of Version, i.e. all components are
+// zero and prefix, pre-release, metadata and hash are empty. Note that a version
+// parsed from 0.0.0 or derived from such a tag is not zero, since it remembers
+// the tag, although it is formatted and compared just like the zero value.
+func (v Version) IsZero() bool {
+	return v == Version{}
+}
+
+// Clone returns a copy of the version.
+func (v Version) Clone() Version {
+	return v
+}
+
+// WithMeta returns a copy of the version with the build metadata set to m.
+func (v Version) WithMeta(m string) Version {
+	v.Meta = m
+	return v
+}
+
+// WithPrefix returns a copy of the version with the prefix set to p.
+func (v Version) WithPrefix(p string) Version {
+	v.Prefix = p
+	return v
+}
+
+// Unprefixed returns a copy of the version without prefix, e.g. 1.2.3 for v1.2.3.
+func (v Version) Unprefixed() Version {
+	return v.WithPrefix("")
+}
+
+// Prefixed returns a copy of the version with the prefix p, replacing the
+// existing prefix, so that the unprefixed and prefixed forms round-trip.
+func (v Version) Prefixed(p string) Version {
+	return v.WithPrefix(p)
+}
+
+// WithCommits returns a copy of the version with the number of commits since the
+// last tag set to n, e.g. to preview the version after n more commits. Negative
+// values are treated as 0.
+func (v Version) WithCommits(n int) Version {
+	if n < 0 {
+		n = 0
+	}
+	v.Commits = n
+	return v
+}
+
+// WithPatch returns a copy of the version with the patch version set to n. In
+// contrast to setting Patch directly, the patch version isn't incremented for
+// commits after the tag anymore, so that n is formatted as is.
+func (v Version) WithPatch(n int) Version {
+	v.Patch = n
+	v.noIncrement = true
+	return v
+}
+
+// WithPreRelease returns a copy of the version with the pre-release set to p. An
+// error is returned if p is not a valid SemVer pre-release, e.g. rc.1.
+func (v Version) WithPreRelease(p string) (Version, error) {
+	if err := validatePreRelease(p); err != nil {
+		return v, err
+	}
+	v.preRelease = p
+	return v, nil
+}
+
+// Describe returns the version in the form that git describe would print it, e.g.
+// v1.2.3-4-gfcf2c8f. If the commit is tagged exactly the tag is returned as is. In
+// case there is no tag at all the abbreviated commit hash is returned, which equals
+// the output of git describe --always. The suffix -dirty is appended for a dirty
+// worktree like git describe --dirty does.
+func (v Version) Describe() string {
+	var dirty string
+	if v.Dirty {
+		dirty = DefaultDirtyMark
+	}
+	hash := v.Hash
+	if len(hash) > 7 {
+		hash = hash[:7]
+	}
+	if v.tag == "" {
+		return hash + dirty
+	}
+	if v.Commits <= 0 {
+		return v.tag + dirty
+	}
+	return fmt.Sprintf("%s-%d-g%s%s", v.tag, v.Commits, hash, dirty)
+}
+
+// DefaultDirtyMark is the suffix that git describe --dirty appends for a dirty
+// worktree.
+const DefaultDirtyMark = "-dirty"
+
+var describeRe = regexp.MustCompile(`^(.+)-([0-9]+)-g([0-9a-f]{4,40})$`)
+
+// NewFromDescribe derives a version from the output of git describe --tags like
+// v1.2.3-4-gfcf2c8f or v1.2.3 for a tagged commit. If the output ends with the
+// mark of git describe --dirty, it is stripped and Dirty is set. The mark is
+// DefaultDirtyMark unless another one is given with WithDirtyMark, like
+// --dirty=<mark> does.
+func NewFromDescribe(s string, opts ...Option) (Version, error) {
+	o := newOptions(opts)
+	head := RepoHead{LastTag: s}
+	if o.dirtyMark != "" && strings.HasSuffix(s, o.dirtyMark) {
+		head.LastTag = strings.TrimSuffix(s, o.dirtyMark)
+		head.Dirty = true
+	}
+	if m := describeRe.FindStringSubmatch(head.LastTag); m != nil {
+		commits, err := strconv.Atoi(m[2])
+		if err != nil {
+			return Version{}, fmt.Errorf("invalid number of commits in %s: %w", s, err)
+		}
+		head.LastTag, head.CommitsSinceTag, head.Hash = m[1], commits, m[3]
+	}
+	if head.LastTag == "" {
+		return Version{}, fmt.Errorf("invalid describe output: %s", s)
+	}
+	head.Tags = []string{head.LastTag}
+	return o.newFromHead(&head)
+}
+
+// ErrNoTags is returned if WithRequiredTags is used and no tag has been found.
+var ErrNoTags = errors.New("no tag found: make sure that tags are fetched, e.g. with git fetch --tags or a full clone")
+
+// NewFromHead derives a version from the describe information of a repository head.
+func NewFromHead(head *RepoHead, opts ...Option) (Version, error) {
+	return newOptions(opts).newFromHead(head)
+}
+
+// Head returns the describe information v has been derived from, so that
+// NewFromHead derives the same version again. Options that modify the version
+// like WithCommitOffset are already reflected in the number of commits, and the
+// tags pointing to the commit are not included.
+func (v Version) Head() *RepoHead {
+	return &RepoHead{
+		LastTag:         v.tag,
+		CommitsSinceTag: v.Commits,
+		Hash:            v.Hash,
+		Branch:          v.branch,
+		CommitTime:      v.CommitTime,
+		TagTime:         v.TagTime,
+		Dirty:           v.Dirty,
+	}
+}
+
+// Format derives the version from head like NewFromHead and formats it according
+// to format, see Version.Format.
+func Format(head *RepoHead, format string, opts ...Option) (string, error) {
+	v, err := NewFromHead(head, opts...)
+	if err != nil {
+		return "", err
+	}
+	return v.Format(format)
+}
+
+func (o *options) newFromHead(head *RepoHead) (Version, error) {
+	if o.requireTags && head.LastTag == "" {
+		return Version{}, ErrNoTags
+	}
+	if o.strictTags {
+		if err := checkConflictingTags(head.Tags); err != nil {
+			return Version{}, err
+		}
+	}
+	v := Version{
+		Commits:     head.CommitsSinceTag,
+		Hash:        head.Hash,
+		CommitTime:  head.CommitTime,
+		TagTime:     head.TagTime,
+		Dirty:       head.Dirty,
+		tag:         head.LastTag,
+		noIncrement: o.noIncrement,
+	}
+	if o.devSeparator != "" {
+		if err := validateDevSeparator(o.devSeparator); err != nil {
+			return Version{}, err
+		}
+		v.devSeparator = o.devSeparator
+	}
+	if v.Commits > 0 {
+		v.Commits += o.commitOffset
+		if v.Commits < 0 {
+			v.Commits = 0
+		}
+	}
+	if o.buildNumber > 0 && (v.Commits > 0 || o.forcePreRelease) {
+		v.Commits = o.buildNumber
+	}
+	if o.branchPreRelease && head.Branch != "" && !o.isMainBranch(head.Branch) {
+		v.branch = sanitizeIdentifier(head.Branch)
+	}
+	var version string
+	v.Prefix, version = o.splitTag(head.LastTag)
+	if o.strictSemver && v.Prefix != "" {
+		return v, fmt.Errorf("tag %s has the prefix %s, which is not allowed by SemVer", head.LastTag, v.Prefix)
+	}
+	if head.LastTag == "" && o.component != "" {
+		v.Prefix = o.component + "/"
 	}
-	version := strings.TrimPrefix(head.LastTag, v.Prefix)
 	if strings.Contains(version, "+") {
-		parts := strings.Split(version, "+")
+		parts := strings.SplitN(version, "+", 2)
 		version = parts[0]
 		v.Meta = parts[1]
-	} else if head.CommitsSinceTag > 0 {
-		v.Meta = head.Hash[:8]
+		if v.Meta == "" || strings.Contains(v.Meta, "+") {
+			return v, fmt.Errorf("invalid build metadata %q in %s", v.Meta, head.LastTag)
+		}
+		if o.appendHash && v.Commits > 0 {
+			v.Meta += "." + o.metaHash(head.Hash)
+		}
+	} else if v.Commits > 0 && o.metaCommits {
+		v.Meta = strconv.Itoa(v.Commits)
+	} else if v.Commits > 0 {
+		v.Meta = o.metaHash(head.Hash)
 	}
-	if strings.Contains(version, "-") {
-		parts := strings.Split(version, "-")
-		version = parts[0]
-		v.preRelease = parts[1]
+	if i := strings.IndexByte(version, '-'); i >= 0 {
+		// Only the first hyphen separates the pre-release, which may contain
+		// further hyphens, e.g. 1.2.3-alpha-beta.1.
+		version, v.preRelease = version[:i], version[i+1:]
+		if v.preRelease == "" {
+			return v, fmt.Errorf("empty pre-release after - in %s", head.LastTag)
+		}
+	}
+
+	if o.stripPrefix {
+		v.Prefix = ""
 	}
 
+	if version == "" && head.LastTag != "" {
+		return v, fmt.Errorf("git version tag must contain 3 components: X.Y.Z: Got %s", head.LastTag)
+	}
 	if version == "" {
 		v.Major = 0
 		v.Minor = 0
@@ -168,6 +511,9 @@ func NewFromHead(head *RepoHead) (Version, error) {
 	}
 
 	parts := strings.Split(version, ".")
+	if len(parts) == 2 && o.allowShortTags {
+		parts = append(parts, "0")
+	}
 	if len(parts) != 3 {
 		return v, fmt.Errorf("git version tag must contain 3 components: X.Y.Z: Got %s", version)
 	}
@@ -187,6 +533,272 @@ func NewFromHead(head *RepoHead) (Version, error) {
 	return v, nil
 }
 
+// DefaultAbbrev is the number of characters the commit hash is abbreviated to in
+// the build metadata, unless WithAbbrev is used.
+const DefaultAbbrev = 8
+
+// shortHash abbreviates a commit hash to n characters.
+func shortHash(hash string, n int) string {
+	if len(hash) > n {
+		return hash[:n]
+	}
+	return hash
+}
+
+// metaHash returns the abbreviated hash that is used as build metadata, prefixed
+// with g like git describe does if WithGitHashPrefix is used.
+func (o *options) metaHash(hash string) string {
+	n := DefaultAbbrev
+	if o.abbrev > 0 {
+		n = o.abbrev
+	}
+	if o.gitHashPrefix && hash != "" {
+		return "g" + shortHash(hash, n)
+	}
+	return shortHash(hash, n)
+}
+
+// splitTag splits a tag into its prefix and the version. The prefix consists of
+// the component path of monorepo tags up to the last slash and the DefaultPrefix,
+// e.g. api/v for the tag api/v1.2.3. If the regular expression passed to
+// WithMatchRegex has a capture group, everything in front of the first group
+// belongs to the prefix instead, e.g. api- for the tag api-1.2.3 and the
+// expression ^api-(.+)$.
+func (o *options) splitTag(tag string) (string, string) {
+	var prefix string
+	if loc := o.captureGroup(tag); loc != nil {
+		prefix, tag = tag[:loc[2]], tag[loc[2]:loc[3]]
+	} else if i := strings.LastIndexByte(tag, '/'); i >= 0 {
+		prefix, tag = tag[:i+1], tag[i+1:]
+	}
+	if strings.HasPrefix(tag, DefaultPrefix) {
+		prefix += DefaultPrefix
+		tag = tag[len(DefaultPrefix):]
+	}
+	return prefix, tag
+}
+
+// captureGroup returns the submatch indices of the regular expression passed to
+// WithMatchRegex if it has a capture group that matches the tag.
+func (o *options) captureGroup(tag string) []int {
+	if o.matchRegex == nil || o.matchRegex.NumSubexp() == 0 {
+		return nil
+	}
+	if loc := o.matchRegex.FindStringSubmatchIndex(tag); loc != nil && loc[2] >= 0 {
+		return loc
+	}
+	return nil
+}
+
+// checkConflictingTags returns an error if the given tags don't all denote the
+// same version. Tags that can't be parsed as version are ignored.
+func checkConflictingTags(tags []string) error {
+	var (
+		versions []string
+		first    Version
+	)
+	conflict := false
+	for _, tag := range tags {
+		v, err := Parse(tag)
+		if err != nil {
+			continue
+		}
+		if len(versions) == 0 {
+			first = v
+		} else if v.Compare(first) != 0 {
+			conflict = true
+		}
+		versions = append(versions, tag)
+	}
+	if conflict {
+		return fmt.Errorf("conflicting tags point to the same commit: %s", strings.Join(versions, ", "))
+	}
+	return nil
+}
+
+// Parse parses a version string like v1.2.3-rc.1+special. The prefix v is detected
+// automatically. Like the SemVer spec, Parse rejects leading zeros in the major,
+// minor and patch version and invalid pre-release or metadata identifiers.
+func Parse(s string) (Version, error) {
+	if s == "" {
+		return Version{}, errors.New("empty version string")
+	}
+	v, err := NewFromHead(&RepoHead{LastTag: s})
+	if err != nil {
+		return Version{}, err
+	}
+	core := strings.TrimPrefix(s, v.Prefix)
+	if i := strings.IndexAny(core, "-+"); i >= 0 {
+		core = core[:i]
+	}
+	for _, n := range strings.Split(core, ".") {
+		if len(n) > 1 && n[0] == '0' {
+			return Version{}, fmt.Errorf("numeric version component %q has leading zeros in %s", n, s)
+		}
+	}
+	if err := validatePreRelease(v.preRelease); err != nil {
+		return Version{}, err
+	}
+	if err := validateMeta(v.Meta); err != nil {
+		return Version{}, err
+	}
+	return v, nil
+}
+
+// StrictParse is like Parse but rejects versions with any prefix like the v in
+// v1.2.3, which is not allowed by the SemVer spec.
+func StrictParse(s string) (Version, error) {
+	v, err := Parse(s)
+	if err != nil {
+		return Version{}, err
+	}
+	if v.Prefix != "" {
+		return Version{}, fmt.Errorf("version %s has the prefix %s, which is not allowed by SemVer", s, v.Prefix)
+	}
+	return v, nil
+}
+
+// Compare returns -1, 0 or 1 depending on whether v has a lower, equal or higher
+// precedence than other according to the SemVer spec. The versions are compared
+// as they are formatted, i.e. including the implicit patch increment and the
+// dev.<n> pre-release. Prefix and build metadata are not taken into account, the
+// prefix is purely cosmetic, so that v1.2.3 and 1.2.3 are equal.
+// Pre-release channels are compared lexically, which orders alpha < beta < rc but
+// also rc < snapshot. Use CompareChannels for other orders.
+func (v Version) Compare(other Version) int {
+	if c := v.compareCore(other); c != 0 {
+		return c
+	}
+	return comparePreRelease(v.PreRelease(), other.PreRelease())
+}
+
+// Equal reports whether v and other have the same precedence, see Compare. Like
+// Compare it ignores the prefix, which is cosmetic, so that v1.2.3 equals 1.2.3.
+func (v Version) Equal(other Version) bool {
+	return v.Compare(other) == 0
+}
+
+// SortVersions sorts the versions in ascending order of their precedence, see
+// Compare. The prefix is ignored and versions with the same precedence keep their
+// order.
+func SortVersions(versions []Version) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].Compare(versions[j]) < 0
+	})
+}
+
+// CompareChannels is like Compare, but the leading identifiers of the
+// pre-releases are ranked by their position in channels if both are listed, e.g.
+// dev < nightly < beta for the channels dev, nightly and beta. Pre-releases of
+// the same or unlisted channels are compared like Compare does.
+func (v Version) CompareChannels(other Version, channels ...string) int {
+	if c := v.compareCore(other); c != 0 {
+		return c
+	}
+	a, b := v.PreRelease(), other.PreRelease()
+	if a != "" && b != "" {
+		ac, bc := channelRank(a, channels), channelRank(b, channels)
+		switch {
+		case ac < 0 || bc < 0 || ac == bc:
+		case ac < bc:
+			return -1
+		default:
+			return 1
+		}
+	}
+	return comparePreRelease(a, b)
+}
+
+// channelRank returns the index of the leading identifier of the pre-release in
+// channels or -1 if it isn't listed.
+func channelRank(preRelease string, channels []string) int {
+	channel := strings.SplitN(preRelease, ".", 2)[0]
+	for i, c := range channels {
+		if c == channel {
+			return i
+		}
+	}
+	return -1
+}
+
+func (v Version) compareCore(other Version) int {
+	for _, d := range []int{
+		v.Major - other.Major,
+		v.Minor - other.Minor,
+		v.effectivePatch() - other.effectivePatch(),
+	} {
+		if d < 0 {
+			return -1
+		} else if d > 0 {
+			return 1
+		}
+	}
+	return 0
+}
+
+// comparePreRelease compares two pre-release versions. A version without a
+// pre-release has a higher precedence than one with a pre-release. Otherwise the
+// dot-separated identifiers are compared from left to right. Numeric identifiers
+// are compared numerically and have lower precedence than alphanumeric ones, which
+// are compared lexically. A larger set of identifiers has a higher precedence, if
+// all preceding identifiers are equal.
+func comparePreRelease(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if c := compareIdentifier(as[i], bs[i]); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(as) < len(bs):
+		return -1
+	case len(as) > len(bs):
+		return 1
+	}
+	return 0
+}
+
+func compareIdentifier(a, b string) int {
+	an, bn := isNumeric(a), isNumeric(b)
+	switch {
+	case an && bn:
+		// compare by length first, so that numbers beyond uint64 work as well
+		if len(a) != len(b) {
+			if len(a) < len(b) {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a, b)
+	case an:
+		return -1
+	case bn:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// isNumeric reports whether the identifier only consists of digits.
+func isNumeric(id string) bool {
+	if id == "" {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < '0' || id[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
 // NewFromRepo calculates a semantic version for the head commit of the repo at path.
 // If the latest commit is not tagged, the version will have a pre-release-suffix
 // appended to it (e.g.: 1.2.3-dev.3+fcf2c8f). The suffix has the format dev.<n>+<hash>,
@@ -196,11 +808,77 @@ func NewFromHead(head *RepoHead) (Version, error) {
 // If the last tag has itself a pre-release-identifier and the last commit is not tagged,
 // NewFromRepo will not increment the patch-level version.
 // The not SemVer commpliant but commonly used prefix v will be automatically detected.
-func NewFromRepo(path string) (Version, error) {
-	head, err := GitDescribe(path)
+// If path is not a git repository, the version is read from an archival file as
+// written by WriteArchival, if there is one.
+func NewFromRepo(path string, opts ...Option) (Version, error) {
+	head, err := GitDescribe(path, opts...)
+	if errors.Is(err, git.ErrRepositoryNotExists) && hasArchival(path) {
+		return NewFromArchival(path, opts...)
+	}
 	if err != nil {
 		return Version{}, err
 	}
-	v, err := NewFromHead(head)
+	v, err := NewFromHead(head, opts...)
 	return v, err
 }
+
+// NewFromRepoSince calculates the version of the repository at path like
+// NewFromRepo, but relative to the given tag instead of the nearest one, see
+// GitDescribeSince. An error wrapping ErrNotDescendant is returned if HEAD is not
+// a descendant of the tag.
+func NewFromRepoSince(path, tag string, opts ...Option) (Version, error) {
+	head, err := GitDescribeSince(path, tag, opts...)
+	if err != nil {
+		return Version{}, err
+	}
+	return NewFromHead(head, opts...)
+}
+
+// Validate checks that the version is a valid SemVer 2.0 version, i.e. that the
+// major, minor and patch versions are not negative and the pre-release and build
+// metadata consist of valid identifiers only.
+func (v Version) Validate() error {
+	for _, c := range []struct {
+		name  string
+		value int
+	}{{"major", v.Major}, {"minor", v.Minor}, {"patch", v.Patch}, {"commits", v.Commits}} {
+		if c.value < 0 {
+			return fmt.Errorf("invalid version: %s must not be negative, got %d", c.name, c.value)
+		}
+	}
+	if err := validatePreRelease(v.PreRelease()); err != nil {
+		return fmt.Errorf("invalid version: %w", err)
+	}
+	if err := validateMeta(v.Meta); err != nil {
+		return fmt.Errorf("invalid version: %w", err)
+	}
+	return nil
+}
+
+var identifierRe = regexp.MustCompile(`^[0-9A-Za-z-]+$`)
+
+// validatePreRelease checks that every dot-separated identifier of the pre-release
+// p is non-empty, consists of alphanumerics and hyphens only and has no leading
+// zeros if it is numeric.
+func validatePreRelease(p string) error {
+	if p == "" {
+		return nil
+	}
+	for _, id := range strings.Split(p, ".") {
+		if !identifierRe.MatchString(id) {
+			return fmt.Errorf("invalid pre-release identifier %q in %s", id, p)
+		}
+		if isNumeric(id) && len(id) > 1 && id[0] == '0' {
+			return fmt.Errorf("numeric pre-release identifier %q has leading zeros in %s", id, p)
+		}
+	}
+	return nil
+}
+
+var illegalIdentifierChars = regexp.MustCompile(`[^0-9A-Za-z-]+`)
+
+// sanitizeIdentifier replaces all characters that are not allowed in a SemVer
+// pre-release identifier with a hyphen, e.g. feature/x becomes feature-x.
+func sanitizeIdentifier(s string) string {
+	return strings.Trim(illegalIdentifierChars.ReplaceAllString(s, "-"), "-")
+}
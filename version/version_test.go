@@ -1,7 +1,9 @@
 package version
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -10,7 +12,7 @@ func TestNewVersion(t *testing.T) {
 	assert := assert.New(t)
 	v, err := NewFromHead(&RepoHead{LastTag: "1.2.3"})
 	assert.NoError(err)
-	assert.Equal(Version{Major: 1, Minor: 2, Patch: 3}, v)
+	assert.Equal(Version{Major: 1, Minor: 2, Patch: 3, tag: "1.2.3"}, v)
 }
 
 func TestNewVersionInvalid(t *testing.T) {
@@ -35,7 +37,7 @@ func TestParse(t *testing.T) {
 	}{
 		{
 			RepoHead{LastTag: "1.2.3", CommitsSinceTag: 4, Hash: "fcf2c8fa"},
-			Version{Major: 1, Minor: 2, Patch: 3, Commits: 4, Meta: "fcf2c8fa"},
+			Version{Major: 1, Minor: 2, Patch: 3, Commits: 4, Meta: "fcf2c8fa", Hash: "fcf2c8fa", tag: "1.2.3"},
 		},
 		{
 			RepoHead{},
@@ -43,35 +45,35 @@ func TestParse(t *testing.T) {
 		},
 		{
 			RepoHead{LastTag: "1.2.3-rc.1"},
-			Version{Major: 1, Minor: 2, Patch: 3, preRelease: "rc.1"},
+			Version{Major: 1, Minor: 2, Patch: 3, preRelease: "rc.1", tag: "1.2.3-rc.1"},
 		},
 		{
 			RepoHead{LastTag: "1.2.3-rc.1", CommitsSinceTag: 2, Hash: "gd92f0b2"},
-			Version{Major: 1, Minor: 2, Patch: 3, preRelease: "rc.1", Commits: 2, Meta: "gd92f0b2"},
+			Version{Major: 1, Minor: 2, Patch: 3, preRelease: "rc.1", Commits: 2, Meta: "gd92f0b2", Hash: "gd92f0b2", tag: "1.2.3-rc.1"},
 		},
 		{
 			RepoHead{LastTag: "3.2.1"},
-			Version{Major: 3, Minor: 2, Patch: 1},
+			Version{Major: 3, Minor: 2, Patch: 1, tag: "3.2.1"},
 		},
 		{
 			RepoHead{LastTag: "v3.2.1"},
-			Version{Prefix: "v", Major: 3, Minor: 2, Patch: 1},
+			Version{Prefix: "v", Major: 3, Minor: 2, Patch: 1, tag: "v3.2.1"},
 		},
 		{
 			RepoHead{LastTag: "3.2.1-liftoff.alpha.1", CommitsSinceTag: 3, Hash: "fcf2c8fa"},
-			Version{Major: 3, Minor: 2, Patch: 1, preRelease: "liftoff.alpha.1", Commits: 3, Meta: "fcf2c8fa"},
+			Version{Major: 3, Minor: 2, Patch: 1, preRelease: "liftoff.alpha.1", Commits: 3, Meta: "fcf2c8fa", Hash: "fcf2c8fa", tag: "3.2.1-liftoff.alpha.1"},
 		},
 		{
 			RepoHead{LastTag: "3.2.1+special"},
-			Version{Major: 3, Minor: 2, Patch: 1, Meta: "special"},
+			Version{Major: 3, Minor: 2, Patch: 1, Meta: "special", tag: "3.2.1+special"},
 		},
 		{
 			RepoHead{LastTag: "3.2.1-rc.2+special"},
-			Version{Major: 3, Minor: 2, Patch: 1, preRelease: "rc.2", Meta: "special"},
+			Version{Major: 3, Minor: 2, Patch: 1, preRelease: "rc.2", Meta: "special", tag: "3.2.1-rc.2+special"},
 		},
 		{
 			RepoHead{LastTag: "3.2.1-rc.2+special", CommitsSinceTag: 3, Hash: "gd92f0b2"},
-			Version{Major: 3, Minor: 2, Patch: 1, preRelease: "rc.2", Commits: 3, Meta: "special"},
+			Version{Major: 3, Minor: 2, Patch: 1, preRelease: "rc.2", Commits: 3, Meta: "special", Hash: "gd92f0b2", tag: "3.2.1-rc.2+special"},
 		},
 	} {
 		v, err := NewFromHead(&test.ref)
@@ -159,6 +161,32 @@ func TestFormat(t *testing.T) {
 	}
 }
 
+func TestHyphenatedPreRelease(t *testing.T) {
+	assert := assert.New(t)
+	for _, test := range []struct {
+		tag string
+		pre string
+		f   string
+		s   string
+	}{
+		{"1.2.3-alpha-beta.1", "alpha-beta.1", FullFormat, "1.2.3-alpha-beta.1"},
+		{"v1.2.3-x-y-z.1+build-7", "x-y-z.1", FullFormat, "v1.2.3-x-y-z.1+build-7"},
+		{"1.2.3-alpha-beta.1", "alpha-beta.1", ReleaseCandidate, "1.2.3-alpha-beta.1"},
+		{"1.2.3--1", "-1", FullFormat, "1.2.3--1"},
+	} {
+		v, err := Parse(test.tag)
+		assert.NoError(err, test.tag)
+		assert.Equal(test.pre, v.PreRelease())
+		s, err := v.Format(test.f)
+		assert.NoError(err)
+		assert.Equal(test.s, s)
+	}
+
+	v, err := NewFromHead(&RepoHead{LastTag: "v1.2.3-alpha-beta.1", CommitsSinceTag: 2, Hash: "fcf2c8f2b1f1b1c1"})
+	assert.NoError(err)
+	assert.Equal("v1.2.3-alpha-beta.1.dev.2+fcf2c8f2", v.String())
+}
+
 func TestReleaseCandidateFormat(t *testing.T) {
 	assert := assert.New(t)
 	for _, test := range []struct {
@@ -186,6 +214,11 @@ func TestReleaseCandidateFormat(t *testing.T) {
 			ReleaseCandidate,
 			"test1.2.3-rc.10",
 		},
+		{
+			Version{Major: 1, Minor: 2, Patch: 3, preRelease: "pre-alpha.1", Commits: 2, Meta: "fcf2c8f"},
+			ReleaseCandidate,
+			"1.2.3-pre-alpha.2",
+		},
 	} {
 		s, err := test.version.Format(test.f)
 		assert.NoError(err)
@@ -197,9 +230,893 @@ func TestReleaseCandidateFormat(t *testing.T) {
 	assert.Equal("", s)
 }
 
+func TestFormatSeparators(t *testing.T) {
+	assert := assert.New(t)
+	v := Version{Major: 1, Minor: 2, Patch: 3, Commits: 10, Meta: "fcf2c8f"}
+	for _, test := range []struct {
+		f string
+		s string
+	}{
+		{"x_y_z", "1_2_4"},
+		{"x-y", "1-2"},
+		{"x.y.z_p", "1.2.4_dev.10"},
+		{"x/y/z", "1/2/4"},
+		{"x.y.z~p~~m", "1.2.4~dev.10~~fcf2c8f"},
+		{"x.y.z-p+m", "1.2.4-dev.10+fcf2c8f"},
+	} {
+		s, err := v.Format(test.f)
+		assert.NoError(err)
+		assert.Equal(test.s, s)
+	}
+
+	s, err := Version{Major: 1, Minor: 2, Patch: 3}.Format("x_y_z_p_m")
+	assert.NoError(err)
+	assert.Equal("1_2_3", s)
+}
+
 func TestInvalidFormat(t *testing.T) {
 	v := Version{Major: 1, Minor: 2, Patch: 3}
 	s, err := v.Format("q")
 	assert.EqualError(t, err, "invalid format: q")
 	assert.Equal(t, "", s)
+
+	for _, f := range []string{"", "x.y.", "x.y.z.1", "-"} {
+		_, err := v.Format(f)
+		assert.EqualError(t, err, "invalid format: "+f)
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	assert := assert.New(t)
+	for _, test := range []struct {
+		ref RepoHead
+		s   string
+	}{
+		{
+			RepoHead{LastTag: "v1.2.3", Hash: "fcf2c8fa8b6e4e0c9e1d"},
+			"v1.2.3",
+		},
+		{
+			RepoHead{LastTag: "v1.2.3", CommitsSinceTag: 4, Hash: "fcf2c8fa8b6e4e0c9e1d"},
+			"v1.2.3-4-gfcf2c8f",
+		},
+		{
+			RepoHead{LastTag: "1.2.3-rc.1+special", CommitsSinceTag: 2, Hash: "d92f0b2ac3"},
+			"1.2.3-rc.1+special-2-gd92f0b2",
+		},
+		{
+			RepoHead{CommitsSinceTag: 3, Hash: "fcf2c8fa8b6e4e0c9e1d"},
+			"fcf2c8f",
+		},
+		{
+			RepoHead{LastTag: "v1.2.3", CommitsSinceTag: 4, Hash: "fcf2c8fa8b6e4e0c9e1d", Dirty: true},
+			"v1.2.3-4-gfcf2c8f-dirty",
+		},
+	} {
+		v, err := NewFromHead(&test.ref)
+		assert.NoError(err)
+		assert.Equal(test.s, v.Describe())
+	}
+}
+
+func TestBranchPreRelease(t *testing.T) {
+	assert := assert.New(t)
+	for _, test := range []struct {
+		ref  RepoHead
+		opts []Option
+		s    string
+	}{
+		{
+			RepoHead{LastTag: "1.2.3", CommitsSinceTag: 5, Hash: "fcf2c8fa", Branch: "feature/x"},
+			nil,
+			"1.2.4-dev.5+fcf2c8fa",
+		},
+		{
+			RepoHead{LastTag: "1.2.3", CommitsSinceTag: 5, Hash: "fcf2c8fa", Branch: "feature/x"},
+			[]Option{WithBranchPreRelease()},
+			"1.2.4-feature-x.dev.5+fcf2c8fa",
+		},
+		{
+			RepoHead{LastTag: "1.2.3-rc.1", CommitsSinceTag: 2, Hash: "fcf2c8fa", Branch: "fix/issue_42"},
+			[]Option{WithBranchPreRelease()},
+			"1.2.3-rc.1.fix-issue-42.dev.2+fcf2c8fa",
+		},
+		{
+			RepoHead{LastTag: "1.2.3", Hash: "fcf2c8fa", Branch: "feature/x"},
+			[]Option{WithBranchPreRelease()},
+			"1.2.3",
+		},
+		{
+			RepoHead{LastTag: "1.2.3", CommitsSinceTag: 5, Hash: "fcf2c8fa", Branch: "master"},
+			[]Option{WithBranchPreRelease()},
+			"1.2.4-dev.5+fcf2c8fa",
+		},
+		{
+			RepoHead{LastTag: "1.2.3", CommitsSinceTag: 5, Hash: "fcf2c8fa", Branch: "main"},
+			[]Option{WithBranchPreRelease()},
+			"1.2.4-dev.5+fcf2c8fa",
+		},
+		{
+			RepoHead{LastTag: "1.2.3", CommitsSinceTag: 5, Hash: "fcf2c8fa", Branch: "main"},
+			[]Option{WithBranchPreRelease("develop")},
+			"1.2.4-main.dev.5+fcf2c8fa",
+		},
+		{
+			RepoHead{LastTag: "1.2.3", CommitsSinceTag: 5, Hash: "fcf2c8fa"},
+			[]Option{WithBranchPreRelease()},
+			"1.2.4-dev.5+fcf2c8fa",
+		},
+		{
+			RepoHead{LastTag: "1.2.3", CommitsSinceTag: 5, Hash: "fcf2c8fa", Branch: "trunk"},
+			[]Option{WithBranchPreRelease(), WithMainBranch("trunk")},
+			"1.2.4-dev.5+fcf2c8fa",
+		},
+		{
+			RepoHead{LastTag: "1.2.3", CommitsSinceTag: 5, Hash: "fcf2c8fa", Branch: "master"},
+			[]Option{WithMainBranch("trunk"), WithBranchPreRelease()},
+			"1.2.4-master.dev.5+fcf2c8fa",
+		},
+		{
+			RepoHead{LastTag: "1.2.3", CommitsSinceTag: 5, Hash: "fcf2c8fa", Branch: "feature/x"},
+			[]Option{WithMainBranch("trunk")},
+			"1.2.4-dev.5+fcf2c8fa",
+		},
+	} {
+		v, err := NewFromHead(&test.ref, test.opts...)
+		assert.NoError(err)
+		assert.Equal(test.s, v.String())
+	}
+}
+
+func TestWith(t *testing.T) {
+	assert := assert.New(t)
+	v := Version{Major: 1, Minor: 2, Patch: 3, Commits: 4, Meta: "fcf2c8fa"}
+	orig := v.Clone()
+	assert.Equal(v, orig)
+
+	assert.Equal("1.2.4-dev.4+special", v.WithMeta("special").String())
+	assert.Equal("v1.2.4-dev.4+fcf2c8fa", v.WithPrefix("v").String())
+	rc, err := v.WithPreRelease("rc.1")
+	assert.NoError(err)
+	assert.Equal("1.2.3-rc.1.dev.4+fcf2c8fa", rc.String())
+	assert.Equal(orig, v)
+
+	for _, p := range []string{"rc..1", "rc.01", "rc.0123456789012345678901", "rc_1", "rc.1+meta", "."} {
+		_, err := v.WithPreRelease(p)
+		assert.Error(err, p)
+	}
+	assert.Equal(orig, v)
+
+	tagged := Version{Major: 1, Minor: 2, Patch: 3}
+	assert.Equal("1.2.4-dev.4", tagged.WithCommits(4).String())
+	assert.Equal("1.2.3", tagged.WithCommits(-1).String())
+	assert.Equal("1.2.3", v.WithCommits(0).WithMeta("").String())
+	rc, _ = tagged.WithPreRelease("rc.1")
+	assert.Equal("1.2.3-rc.1.dev.2", rc.WithCommits(2).String())
+
+	assert.Equal("1.2.7-dev.4+fcf2c8fa", v.WithPatch(7).String())
+	assert.Equal("1.2.7", tagged.WithPatch(7).String())
+
+	prefixed, err := Parse("v1.2.3-rc.1+build.5")
+	assert.NoError(err)
+	assert.Equal("1.2.3-rc.1+build.5", prefixed.Unprefixed().String())
+	assert.Equal("v", prefixed.Prefix)
+	assert.Equal(prefixed.String(), prefixed.Unprefixed().Prefixed("v").String())
+	assert.Equal("release-1.2.3-rc.1+build.5", prefixed.Prefixed("release-").String())
+	assert.Equal(0, prefixed.Unprefixed().Compare(prefixed))
+}
+
+func TestParseString(t *testing.T) {
+	assert := assert.New(t)
+	for _, s := range []string{"1.2.3", "v1.2.3-rc.1", "3.2.1-rc.2+special", "0.10.0", "1.2.3+001"} {
+		v, err := Parse(s)
+		assert.NoError(err)
+		assert.Equal(s, v.String())
+	}
+	for _, s := range []string{"", "1.2", "v1.2.a", "1.2.3-rc..1", "01.2.3", "1.02.3", "1.2.03", "v01.2.3", "1.2.3+a_b", "1.2.3+build..1", "1.2.3+a+b", "1.2.3-", "1.2.3+", "1.2.3-+a"} {
+		_, err := Parse(s)
+		assert.Error(err, s)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	assert := assert.New(t)
+	for _, test := range []struct {
+		a, b Version
+		r    int
+	}{
+		{Version{Major: 1, Minor: 2, Patch: 3}, Version{Major: 1, Minor: 2, Patch: 3}, 0},
+		{Version{Major: 1, Minor: 2, Patch: 3}, Version{Major: 2}, -1},
+		{Version{Major: 1, Minor: 3}, Version{Major: 1, Minor: 2, Patch: 9}, 1},
+		{Version{Major: 1, Minor: 2, Patch: 3, preRelease: "rc.1"}, Version{Major: 1, Minor: 2, Patch: 3}, -1},
+		{Version{Major: 1, Minor: 2, Patch: 3, preRelease: "rc.2"}, Version{Major: 1, Minor: 2, Patch: 3, preRelease: "rc.10"}, -1},
+		{Version{Major: 1, Minor: 2, Patch: 3, Commits: 2}, Version{Major: 1, Minor: 2, Patch: 3}, 1},
+		{Version{Major: 1, Minor: 2, Patch: 3, Commits: 2}, Version{Major: 1, Minor: 2, Patch: 4}, -1},
+		{Version{Major: 1, Minor: 2, Patch: 3, preRelease: "rc.1", Commits: 2}, Version{Major: 1, Minor: 2, Patch: 3, preRelease: "rc.1"}, 1},
+		{Version{Major: 1, Minor: 2, Patch: 3, Meta: "a"}, Version{Major: 1, Minor: 2, Patch: 3, Meta: "b"}, 0},
+	} {
+		assert.Equal(test.r, test.a.Compare(test.b), "%s %s", test.a, test.b)
+		assert.Equal(-test.r, test.b.Compare(test.a), "%s %s", test.b, test.a)
+	}
+}
+
+func TestComparePreRelease(t *testing.T) {
+	assert := assert.New(t)
+	// the example of precedence from the SemVer spec, in ascending order
+	chain := []string{
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+	}
+	for i := range chain {
+		a, err := Parse(chain[i])
+		assert.NoError(err)
+		for j := range chain {
+			b, err := Parse(chain[j])
+			assert.NoError(err)
+			expected := 0
+			if i < j {
+				expected = -1
+			} else if i > j {
+				expected = 1
+			}
+			assert.Equal(expected, a.Compare(b), "%s %s", a, b)
+		}
+	}
+
+	for _, test := range []struct {
+		a, b string
+	}{
+		{"1.0.0-1", "1.0.0-1.1"},
+		{"1.0.0-2", "1.0.0-10"},
+		{"1.0.0-1", "1.0.0-a"},
+		{"1.0.0-10", "1.0.0-1a"},
+		{"1.0.0-rc.9", "1.0.0-rc.10"},
+		{"1.0.0-x.18446744073709551615", "1.0.0-x.18446744073709551616"},
+		{"1.0.0-x.99999999999999999999", "1.0.0-x.alpha"},
+	} {
+		a, err := Parse(test.a)
+		assert.NoError(err)
+		b, err := Parse(test.b)
+		assert.NoError(err)
+		assert.Equal(-1, a.Compare(b), "%s %s", a, b)
+		assert.Equal(1, b.Compare(a), "%s %s", b, a)
+	}
+}
+
+func TestCompareIgnoresPrefix(t *testing.T) {
+	assert := assert.New(t)
+	prefixed, err := Parse("v1.2.3")
+	assert.NoError(err)
+	plain, err := Parse("1.2.3")
+	assert.NoError(err)
+	assert.Equal(0, prefixed.Compare(plain))
+	assert.True(prefixed.Equal(plain))
+	assert.True(plain.Equal(prefixed.WithPrefix("release-")))
+	assert.False(plain.Equal(plain.WithCommits(1)))
+
+	var versions []Version
+	for _, s := range []string{"v1.10.0", "1.2.3", "v1.2.3-rc.1", "v1.2.3", "1.2.4", "0.9.0"} {
+		v, err := Parse(s)
+		assert.NoError(err)
+		versions = append(versions, v)
+	}
+	versions[4] = versions[4].WithPrefix("release-")
+	SortVersions(versions)
+	var sorted []string
+	for _, v := range versions {
+		sorted = append(sorted, v.String())
+	}
+	assert.Equal([]string{"0.9.0", "v1.2.3-rc.1", "1.2.3", "v1.2.3", "release-1.2.4", "v1.10.0"}, sorted)
+}
+
+func TestCompareChannels(t *testing.T) {
+	assert := assert.New(t)
+	for _, test := range []struct {
+		a, b     string
+		lexical  int
+		channels int
+	}{
+		{"1.2.3-alpha.1", "1.2.3-beta.1", -1, 1},
+		{"1.2.3-beta.2", "1.2.3-rc.1", -1, -1},
+		{"1.2.3-rc.1", "1.2.3-snapshot.1", -1, -1},
+		{"1.2.3-dev.5", "1.2.3-nightly.1", -1, -1},
+		{"1.2.3-nightly.1", "1.2.3-alpha.1", 1, -1},
+		{"1.2.3-nightly.1", "1.2.3-nightly.2", -1, -1},
+		{"1.2.3-custom.1", "1.2.3-nightly.1", -1, -1},
+		{"1.2.3-nightly.1", "1.2.3", -1, -1},
+		{"1.2.3-rc.1", "1.2.2", 1, 1},
+	} {
+		a, err := Parse(test.a)
+		assert.NoError(err)
+		b, err := Parse(test.b)
+		assert.NoError(err)
+		assert.Equal(test.lexical, a.Compare(b), "%s %s", a, b)
+		assert.Equal(-test.lexical, b.Compare(a), "%s %s", b, a)
+		channels := []string{"dev", "nightly", "beta", "alpha", "rc"}
+		assert.Equal(test.channels, a.CompareChannels(b, channels...), "%s %s", a, b)
+		assert.Equal(-test.channels, b.CompareChannels(a, channels...), "%s %s", b, a)
+	}
+}
+
+func TestStrictTags(t *testing.T) {
+	assert := assert.New(t)
+	head := &RepoHead{LastTag: "v1.2.3", Tags: []string{"1.2.3", "v1.2.3", "v1.2.3+build.1", "latest"}}
+	v, err := NewFromHead(head, WithStrictTags())
+	assert.NoError(err)
+	assert.Equal("v1.2.3", v.String())
+
+	head = &RepoHead{LastTag: "v2.0.0", Tags: []string{"v1.2.3", "v2.0.0"}}
+	_, err = NewFromHead(head, WithStrictTags())
+	assert.EqualError(err, "conflicting tags point to the same commit: v1.2.3, v2.0.0")
+	v, err = NewFromHead(head)
+	assert.NoError(err)
+	assert.Equal("v2.0.0", v.String())
+}
+
+func TestWithoutAutoIncrement(t *testing.T) {
+	assert := assert.New(t)
+	for _, test := range []struct {
+		ref         RepoHead
+		s           string
+		noIncrement string
+	}{
+		{
+			RepoHead{LastTag: "1.2.3", CommitsSinceTag: 3, Hash: "fcf2c8fa"},
+			"1.2.4-dev.3+fcf2c8fa",
+			"1.2.3-dev.3+fcf2c8fa",
+		},
+		{
+			RepoHead{LastTag: "1.2.3"},
+			"1.2.3",
+			"1.2.3",
+		},
+		{
+			RepoHead{LastTag: "1.2.3-rc.1", CommitsSinceTag: 3, Hash: "fcf2c8fa"},
+			"1.2.3-rc.1.dev.3+fcf2c8fa",
+			"1.2.3-rc.1.dev.3+fcf2c8fa",
+		},
+	} {
+		v, err := NewFromHead(&test.ref)
+		assert.NoError(err)
+		assert.Equal(test.s, v.String())
+		v, err = NewFromHead(&test.ref, WithoutAutoIncrement())
+		assert.NoError(err)
+		assert.Equal(test.noIncrement, v.String())
+	}
+}
+
+func TestCommitOffset(t *testing.T) {
+	assert := assert.New(t)
+	for _, test := range []struct {
+		ref    RepoHead
+		offset int
+		s      string
+	}{
+		{RepoHead{LastTag: "1.2.3", CommitsSinceTag: 3, Hash: "fcf2c8fa"}, 1000, "1.2.4-dev.1003+fcf2c8fa"},
+		{RepoHead{LastTag: "1.2.3"}, 1000, "1.2.3"},
+		{RepoHead{LastTag: "1.2.3-rc.1", CommitsSinceTag: 3, Hash: "fcf2c8fa"}, -1, "1.2.3-rc.1.dev.2+fcf2c8fa"},
+		{RepoHead{LastTag: "1.2.3", CommitsSinceTag: 3, Hash: "fcf2c8fa"}, -5, "1.2.3"},
+	} {
+		v, err := NewFromHead(&test.ref, WithCommitOffset(test.offset))
+		assert.NoError(err)
+		assert.Equal(test.s, v.String())
+	}
+}
+
+func TestBuildNumber(t *testing.T) {
+	assert := assert.New(t)
+	for _, test := range []struct {
+		ref  RepoHead
+		opts []Option
+		s    string
+	}{
+		{RepoHead{LastTag: "1.2.3", CommitsSinceTag: 3, Hash: "fcf2c8fa"}, []Option{WithBuildNumber(42)}, "1.2.4-dev.42+fcf2c8fa"},
+		{RepoHead{LastTag: "1.2.3-rc.1", CommitsSinceTag: 3, Hash: "fcf2c8fa"}, []Option{WithBuildNumber(42), WithMetaCommits()}, "1.2.3-rc.1.dev.42+42"},
+		{RepoHead{LastTag: "1.2.3", CommitsSinceTag: 3, Hash: "fcf2c8fa"}, []Option{WithBuildNumber(42), WithCommitOffset(1000)}, "1.2.4-dev.42+fcf2c8fa"},
+		{RepoHead{LastTag: "1.2.3", CommitsSinceTag: 3, Hash: "fcf2c8fa"}, []Option{WithBuildNumber(0)}, "1.2.4-dev.3+fcf2c8fa"},
+		{RepoHead{LastTag: "1.2.3", Hash: "fcf2c8fa"}, []Option{WithBuildNumber(42)}, "1.2.3"},
+		{RepoHead{LastTag: "1.2.3", Hash: "fcf2c8fa"}, []Option{WithBuildNumber(42), WithForcePreRelease()}, "1.2.4-dev.42+fcf2c8fa"},
+		{RepoHead{LastTag: "1.2.3", Hash: "fcf2c8fa"}, []Option{WithForcePreRelease()}, "1.2.3"},
+	} {
+		v, err := NewFromHead(&test.ref, test.opts...)
+		assert.NoError(err)
+		assert.Equal(test.s, v.String())
+	}
+}
+
+func TestGitHashPrefix(t *testing.T) {
+	assert := assert.New(t)
+	head := &RepoHead{LastTag: "v1.2.3", CommitsSinceTag: 3, Hash: "fcf2c8fa52f6e1c3"}
+	v, err := NewFromHead(head)
+	assert.NoError(err)
+	assert.Equal("v1.2.4-dev.3+fcf2c8fa", v.String())
+	v, err = NewFromHead(head, WithGitHashPrefix())
+	assert.NoError(err)
+	assert.Equal("v1.2.4-dev.3+gfcf2c8fa", v.String())
+
+	v, err = NewFromHead(&RepoHead{LastTag: "v1.2.3+build.7", CommitsSinceTag: 3, Hash: "fcf2c8fa52f6e1c3"}, WithGitHashPrefix(), WithAppendHash())
+	assert.NoError(err)
+	assert.Equal("v1.2.4-dev.3+build.7.gfcf2c8fa", v.String())
+
+	v, err = NewFromHead(&RepoHead{LastTag: "v1.2.3", Hash: "fcf2c8fa52f6e1c3"}, WithGitHashPrefix())
+	assert.NoError(err)
+	assert.Equal("v1.2.3", v.String())
+}
+
+func TestMetaCommits(t *testing.T) {
+	assert := assert.New(t)
+	head := &RepoHead{LastTag: "v1.2.3", CommitsSinceTag: 3, Hash: "fcf2c8fa52f6e1c3"}
+	v, err := NewFromHead(head, WithMetaCommits())
+	assert.NoError(err)
+	assert.Equal("v1.2.4-dev.3+3", v.String())
+	assert.Regexp(`^[0-9]+$`, v.Meta)
+	assert.NoError(v.Validate())
+
+	v, err = NewFromHead(head, WithMetaCommits(), WithCommitOffset(1000), WithGitHashPrefix())
+	assert.NoError(err)
+	assert.Equal("v1.2.4-dev.1003+1003", v.String())
+
+	v, err = NewFromHead(&RepoHead{LastTag: "v1.2.3", Hash: "fcf2c8fa52f6e1c3"}, WithMetaCommits())
+	assert.NoError(err)
+	assert.Equal("v1.2.3", v.String())
+}
+
+func TestHead(t *testing.T) {
+	assert := assert.New(t)
+	for _, head := range []RepoHead{
+		{LastTag: "v1.2.3", CommitsSinceTag: 3, Hash: "fcf2c8fa52f6e1c3", CommitTime: time.Unix(1600000000, 0)},
+		{LastTag: "1.2.3-rc.1+build.7", CommitsSinceTag: 2, Hash: "fcf2c8fa52f6e1c3", Branch: "feature-x"},
+		{LastTag: "v1.2.3"},
+		{CommitsSinceTag: 5, Hash: "fcf2c8fa52f6e1c3"},
+	} {
+		v, err := NewFromHead(&head, WithBranchPreRelease())
+		assert.NoError(err)
+		again, err := NewFromHead(v.Head(), WithBranchPreRelease())
+		assert.NoError(err)
+		assert.Equal(v, again)
+	}
+}
+
+func TestFormatHead(t *testing.T) {
+	assert := assert.New(t)
+	for _, test := range []struct {
+		head   RepoHead
+		format string
+	}{
+		{RepoHead{LastTag: "v1.2.3", CommitsSinceTag: 3, Hash: "fcf2c8fa52f6e1c3"}, FullFormat},
+		{RepoHead{LastTag: "v1.2.3", CommitsSinceTag: 3, Hash: "fcf2c8fa52f6e1c3"}, NoMetaFormat},
+		{RepoHead{LastTag: "1.2.3-rc.1"}, "x_y_z-p"},
+	} {
+		v, err := NewFromHead(&test.head)
+		assert.NoError(err)
+		expected, err := v.Format(test.format)
+		assert.NoError(err)
+		s, err := Format(&test.head, test.format)
+		assert.NoError(err)
+		assert.Equal(expected, s)
+	}
+
+	s, err := Format(&RepoHead{LastTag: "v1.2.3"}, NoPreFormat, WithoutPrefix())
+	assert.NoError(err)
+	assert.Equal("1.2.3", s)
+	_, err = Format(&RepoHead{LastTag: "v1.2"}, FullFormat)
+	assert.Error(err)
+	_, err = Format(&RepoHead{LastTag: "v1.2.3"}, "invalid")
+	assert.Error(err)
+}
+
+func TestAbbrev(t *testing.T) {
+	assert := assert.New(t)
+	head := &RepoHead{LastTag: "v1.2.3", CommitsSinceTag: 3, Hash: "fcf2c8fa52f6e1c3b0a1d2e3f4a5b6c7d8e9f0a1"}
+	for _, test := range []struct {
+		n    int
+		meta string
+	}{
+		{7, "fcf2c8f"},
+		{12, "fcf2c8fa52f6"},
+		{1, "fcf2"},
+		{50, head.Hash},
+	} {
+		v, err := NewFromHead(head, WithAbbrev(test.n))
+		assert.NoError(err)
+		assert.Equal(test.meta, v.Meta)
+	}
+	v, err := NewFromHead(head, WithAbbrev(7), WithGitHashPrefix())
+	assert.NoError(err)
+	assert.Equal("v1.2.4-dev.3+gfcf2c8f", v.String())
+}
+
+func TestValidate(t *testing.T) {
+	assert := assert.New(t)
+	for _, test := range []struct {
+		v     Version
+		valid bool
+	}{
+		{Version{Major: 1, Minor: 2, Patch: 3}, true},
+		{Version{Major: 1, Minor: 2, Patch: 3, preRelease: "rc.1", Commits: 2, Meta: "fcf2c8fa"}, true},
+		{Version{Major: 1, Minor: 2, Patch: 3, Meta: "build.007"}, true},
+		{Version{Major: -1, Minor: 2, Patch: 3}, false},
+		{Version{Major: 1, Minor: -2, Patch: 3}, false},
+		{Version{Major: 1, Minor: 2, Patch: -3}, false},
+		{Version{Major: 1, Minor: 2, Patch: 3, Commits: -1}, false},
+		{Version{Major: 1, Minor: 2, Patch: 3, preRelease: "rc_1"}, false},
+		{Version{Major: 1, Minor: 2, Patch: 3, preRelease: "rc..1"}, false},
+		{Version{Major: 1, Minor: 2, Patch: 3, preRelease: "rc.01"}, false},
+		{Version{Major: 1, Minor: 2, Patch: 3, preRelease: "0123456789012345678901"}, false},
+		{Version{Major: 1, Minor: 2, Patch: 3, Meta: "build+1"}, false},
+		{Version{Major: 1, Minor: 2, Patch: 3, Meta: "build."}, false},
+	} {
+		err := test.v.Validate()
+		if test.valid {
+			assert.NoError(err, "%#v", test.v)
+		} else {
+			assert.Error(err, "%#v", test.v)
+		}
+	}
+}
+
+func TestFormatCommitTime(t *testing.T) {
+	assert := assert.New(t)
+	v := Version{Major: 1, Minor: 2, Patch: 3, Meta: "fcf2c8fa"}
+	s, err := v.Format("x.y.z+d")
+	assert.NoError(err)
+	assert.Equal("1.2.3", s)
+
+	v.CommitTime = time.Date(2020, 12, 8, 17, 45, 0, 0, time.FixedZone("CET", 3600))
+	s, err = v.Format("x.y.z+m.d")
+	assert.NoError(err)
+	assert.Equal("1.2.3+fcf2c8fa.20201208164500", s)
+}
+
+func TestFormatRawPreRelease(t *testing.T) {
+	assert := assert.New(t)
+	for _, test := range []struct {
+		head RepoHead
+		p, P string
+	}{
+		{RepoHead{LastTag: "v1.2.3-rc.1", CommitsSinceTag: 3, Hash: "fcf2c8fa"}, "v1.2.3-rc.1.dev.3", "v1.2.3-rc.1"},
+		{RepoHead{LastTag: "v1.2.3-rc.1"}, "v1.2.3-rc.1", "v1.2.3-rc.1"},
+		{RepoHead{LastTag: "v1.2.3", CommitsSinceTag: 3, Hash: "fcf2c8fa"}, "v1.2.4-dev.3", "v1.2.4"},
+	} {
+		v, err := NewFromHead(&test.head)
+		assert.NoError(err)
+		s, err := v.Format("x.y.z-p")
+		assert.NoError(err)
+		assert.Equal(test.p, s)
+		s, err = v.Format("x.y.z-P")
+		assert.NoError(err)
+		assert.Equal(test.P, s)
+	}
+	v, err := Parse("1.2.3-rc.1")
+	assert.NoError(err)
+	assert.Equal("rc.1", v.WithCommits(2).PreReleaseRaw())
+	assert.Equal("rc.1.dev.2", v.WithCommits(2).PreRelease())
+}
+
+func TestShortTags(t *testing.T) {
+	assert := assert.New(t)
+	for _, test := range []struct {
+		ref RepoHead
+		s   string
+	}{
+		{RepoHead{LastTag: "v1.2"}, "v1.2.0"},
+		{RepoHead{LastTag: "v1.2-rc.1"}, "v1.2.0-rc.1"},
+		{RepoHead{LastTag: "1.2+build.5"}, "1.2.0+build.5"},
+		{RepoHead{LastTag: "v1.2", CommitsSinceTag: 3, Hash: "fcf2c8fa"}, "v1.2.1-dev.3+fcf2c8fa"},
+		{RepoHead{LastTag: "v1.2.3"}, "v1.2.3"},
+	} {
+		v, err := NewFromHead(&test.ref, WithShortTags())
+		assert.NoError(err)
+		assert.Equal(test.s, v.String())
+	}
+
+	for _, tag := range []string{"v1.2", "v1.2-rc.1", "v1"} {
+		_, err := NewFromHead(&RepoHead{LastTag: tag})
+		assert.Error(err, tag)
+	}
+	_, err := NewFromHead(&RepoHead{LastTag: "v1"}, WithShortTags())
+	assert.Error(err)
+}
+
+func TestFormatPrefix(t *testing.T) {
+	assert := assert.New(t)
+	v := Version{Prefix: "release-", Major: 1, Minor: 2, Patch: 3, preRelease: "rc.1", Commits: 2, Meta: "fcf2c8fa"}
+	for _, test := range []struct {
+		format string
+		s      string
+	}{
+		{FullFormat, "release-1.2.3-rc.1.dev.2+fcf2c8fa"},
+		{NoMetaFormat, "release-1.2.3-rc.1.dev.2"},
+		{NoPreFormat, "release-1.2.3"},
+		{NoPatchFormat, "release-1.2"},
+		{NoMinorFormat, "release-1"},
+		{ReleaseCandidate, "release-1.2.3-rc.2"},
+		{"m", "release-fcf2c8fa"},
+	} {
+		s, err := v.Format(test.format)
+		assert.NoError(err)
+		assert.Equal(test.s, s, test.format)
+		assert.Equal(1, strings.Count(s, v.Prefix), test.format)
+	}
+}
+
+func TestComponentPrefix(t *testing.T) {
+	assert := assert.New(t)
+	v, err := Parse("api/v1.2.3")
+	assert.NoError(err)
+	assert.Equal("api/v", v.Prefix)
+	assert.Equal("api/v1.2.3", v.String())
+	assert.Equal("api/v1.3.0", v.BumpMinor().String())
+
+	v, err = NewFromHead(&RepoHead{LastTag: "services/api/1.2.3-rc.1", CommitsSinceTag: 2, Hash: "fcf2c8fa"})
+	assert.NoError(err)
+	assert.Equal("services/api/1.2.3-rc.1.dev.2+fcf2c8fa", v.String())
+	v, err = NewFromHead(&RepoHead{LastTag: "api/v1.2.3"}, WithoutPrefix())
+	assert.NoError(err)
+	assert.Equal("1.2.3", v.String())
+
+	v, err = NewFromHead(&RepoHead{CommitsSinceTag: 3, Hash: "fcf2c8fa"}, WithComponent("api/"))
+	assert.NoError(err)
+	assert.Equal("api/0.0.1-dev.3+fcf2c8fa", v.String())
+
+	_, err = Parse("api/")
+	assert.Error(err)
+}
+
+func TestFormatHash(t *testing.T) {
+	assert := assert.New(t)
+	hash := "fcf2c8fa8b6e4e0c9e1d0e3b5a7c6d4f2e1a0b9c"
+	v, err := NewFromHead(&RepoHead{LastTag: "v1.2.3", CommitsSinceTag: 2, Hash: hash})
+	assert.NoError(err)
+	s, err := v.Format("x.y.z+H")
+	assert.NoError(err)
+	assert.Equal("v1.2.4+"+hash, s)
+	assert.Len(strings.TrimPrefix(s, "v1.2.4+"), 40)
+
+	s, err = v.Format("x.y.z-p+m.H")
+	assert.NoError(err)
+	assert.Equal("v1.2.4-dev.2+fcf2c8fa."+hash, s)
+
+	s, err = Version{Major: 1, Minor: 2, Patch: 3}.Format("x.y.z+H")
+	assert.NoError(err)
+	assert.Equal("1.2.3", s)
+}
+
+func TestNewFromDescribe(t *testing.T) {
+	assert := assert.New(t)
+	for _, test := range []struct {
+		in    string
+		opts  []Option
+		s     string
+		dirty bool
+	}{
+		{"v1.2.3", nil, "v1.2.3", false},
+		{"v1.2.3-dirty", nil, "v1.2.3", true},
+		{"v1.2.3-4-gabc1234", nil, "v1.2.4-dev.4+abc1234", false},
+		{"v1.2.3-4-gabc1234-dirty", nil, "v1.2.4-dev.4+abc1234", true},
+		{"v1.2.3-rc.1-4-gabc1234-dirty", nil, "v1.2.3-rc.1.dev.4+abc1234", true},
+		{"v1.2.3-0-gabc1234", nil, "v1.2.3", false},
+		{"v1.2.3-4-gabc1234.modified", []Option{WithDirtyMark(".modified")}, "v1.2.4-dev.4+abc1234", true},
+	} {
+		v, err := NewFromDescribe(test.in, test.opts...)
+		assert.NoError(err, test.in)
+		assert.Equal(test.s, v.String(), test.in)
+		assert.Equal(test.dirty, v.Dirty, test.in)
+	}
+
+	v, err := NewFromDescribe("v1.2.3-4-gabc1234-dirty")
+	assert.NoError(err)
+	assert.Equal("v1.2.3-4-gabc1234-dirty", v.Describe())
+	assert.Equal("abc1234", v.Hash)
+	assert.Equal(4, v.Commits)
+
+	_, err = NewFromDescribe("-dirty")
+	assert.EqualError(err, "invalid describe output: -dirty")
+}
+
+func TestDevSeparator(t *testing.T) {
+	assert := assert.New(t)
+	for _, test := range []struct {
+		head RepoHead
+		opts []Option
+		s    string
+	}{
+		{RepoHead{LastTag: "v1.2.3-rc.1", CommitsSinceTag: 3}, nil, "v1.2.3-rc.1.dev.3"},
+		{RepoHead{LastTag: "v1.2.3-rc.1", CommitsSinceTag: 3}, []Option{WithDevSeparator(".")}, "v1.2.3-rc.1.dev.3"},
+		{RepoHead{LastTag: "v1.2.3-rc.1", CommitsSinceTag: 3}, []Option{WithDevSeparator("-")}, "v1.2.3-rc.1-dev.3"},
+		{RepoHead{LastTag: "v1.2.3-rc.1", CommitsSinceTag: 3}, []Option{WithDevSeparator("x")}, "v1.2.3-rc.1xdev.3"},
+		{RepoHead{LastTag: "v1.2.3-rc.1", CommitsSinceTag: 3, Branch: "feature"}, []Option{WithDevSeparator("-"), WithBranchPreRelease()}, "v1.2.3-rc.1-feature.dev.3"},
+		{RepoHead{LastTag: "v1.2.3-rc.1"}, []Option{WithDevSeparator("-")}, "v1.2.3-rc.1"},
+		{RepoHead{LastTag: "v1.2.3", CommitsSinceTag: 3}, []Option{WithDevSeparator("-")}, "v1.2.4-dev.3"},
+	} {
+		v, err := NewFromHead(&test.head, test.opts...)
+		assert.NoError(err)
+		s, err := v.Format(NoMetaFormat)
+		assert.NoError(err)
+		assert.Equal(test.s, s)
+		assert.NoError(v.Validate(), s)
+	}
+	for _, sep := range []string{"..", "_", "+", ".-"} {
+		_, err := NewFromHead(&RepoHead{LastTag: "v1.2.3-rc.1", CommitsSinceTag: 3}, WithDevSeparator(sep))
+		assert.Error(err, sep)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	assert := assert.New(t)
+	for _, test := range []struct {
+		ref RepoHead
+		s   string
+	}{
+		{RepoHead{LastTag: "v1.2.3-RC.1+Build.5"}, "v1.2.3-rc.1+build.5"},
+		{RepoHead{LastTag: "API/v1.2.3-Beta", CommitsSinceTag: 2, Hash: "FCF2C8FA"}, "api/v1.2.3-beta.dev.2+fcf2c8fa"},
+		{RepoHead{LastTag: "v1.2.3", CommitsSinceTag: 2, Hash: "fcf2c8fa", Branch: "Feature/X"}, "v1.2.4-feature-x.dev.2+fcf2c8fa"},
+		{RepoHead{LastTag: "1.2.3"}, "1.2.3"},
+	} {
+		v, err := NewFromHead(&test.ref, WithBranchPreRelease())
+		assert.NoError(err)
+		assert.Equal(test.s, v.Normalize().String())
+	}
+
+	upper, err := Parse("1.0.0-RC.1")
+	assert.NoError(err)
+	beta, err := Parse("1.0.0-beta.1")
+	assert.NoError(err)
+	assert.Equal(-1, upper.Compare(beta))
+	assert.Equal(1, upper.Normalize().Compare(beta))
+}
+
+func TestPreReleaseParts(t *testing.T) {
+	assert := assert.New(t)
+	for _, test := range []struct {
+		v     Version
+		parts []PreReleaseIdentifier
+	}{
+		{Version{Major: 1, preRelease: "rc.1"}, []PreReleaseIdentifier{{"rc", false}, {"1", true}}},
+		{Version{Major: 1, preRelease: "alpha.beta.2"}, []PreReleaseIdentifier{{"alpha", false}, {"beta", false}, {"2", true}}},
+		{Version{Major: 1, preRelease: "rc-1.0x1"}, []PreReleaseIdentifier{{"rc-1", false}, {"0x1", false}}},
+		{Version{Major: 1, preRelease: "rc.1", Commits: 3}, []PreReleaseIdentifier{{"rc", false}, {"1", true}, {"dev", false}, {"3", true}}},
+		{Version{Major: 1}, nil},
+	} {
+		assert.Equal(test.parts, test.v.PreReleaseParts(), test.v.String())
+	}
+	assert.Empty(Version{Major: 1}.PreReleaseParts())
+}
+
+func TestStrictParse(t *testing.T) {
+	assert := assert.New(t)
+	v, err := StrictParse("1.2.3")
+	assert.NoError(err)
+	assert.Equal("1.2.3", v.String())
+	v, err = StrictParse("1.2.3-rc.1+build.5")
+	assert.NoError(err)
+	assert.Equal("1.2.3-rc.1+build.5", v.String())
+
+	_, err = Parse("v1.2.3")
+	assert.NoError(err)
+	_, err = StrictParse("v1.2.3")
+	assert.EqualError(err, "version v1.2.3 has the prefix v, which is not allowed by SemVer")
+	_, err = StrictParse("api/1.2.3")
+	assert.EqualError(err, "version api/1.2.3 has the prefix api/, which is not allowed by SemVer")
+	_, err = StrictParse("1.2")
+	assert.Error(err)
+
+	_, err = NewFromHead(&RepoHead{LastTag: "v1.2.3"}, WithStrictSemver())
+	assert.EqualError(err, "tag v1.2.3 has the prefix v, which is not allowed by SemVer")
+	v, err = NewFromHead(&RepoHead{LastTag: "1.2.3", CommitsSinceTag: 1, Hash: "fcf2c8fa"}, WithStrictSemver())
+	assert.NoError(err)
+	assert.Equal("1.2.4-dev.1+fcf2c8fa", v.String())
+}
+
+func TestFormatCommits(t *testing.T) {
+	assert := assert.New(t)
+	for _, test := range []struct {
+		head   RepoHead
+		format string
+		s      string
+	}{
+		{RepoHead{LastTag: "v1.2.3"}, "x.y.z.n", "v1.2.3.0"},
+		{RepoHead{LastTag: "v1.2.3", CommitsSinceTag: 3}, "x.y.z.n", "v1.2.4.3"},
+		{RepoHead{LastTag: "v1.2.3", CommitsSinceTag: 3}, "x.y-n", "v1.2-3"},
+		{RepoHead{LastTag: "v1.2.3-rc.1", CommitsSinceTag: 3}, "x.y.z-P.n", "v1.2.3-rc.1.3"},
+	} {
+		s, err := Format(&test.head, test.format)
+		assert.NoError(err)
+		assert.Equal(test.s, s)
+	}
+	s, err := Version{Major: 1, Minor: 2, Patch: 3, Commits: -1}.Format("x.y.z.n")
+	assert.NoError(err)
+	assert.Equal("1.2.3.0", s)
+}
+
+func TestNegativeCommits(t *testing.T) {
+	assert := assert.New(t)
+	v := Version{Major: 1, Minor: 2, Patch: 3, Commits: -1, Hash: "fcf2c8fa8b6e4e0c9e1d"}
+	assert.Equal("", v.PreRelease())
+	assert.Equal("1.2.3", v.String())
+	assert.Equal("1.2.3", v.PEP440())
+	assert.Equal("1.2.3.0", v.AssemblyVersion())
+	assert.Equal("1.2.3", v.Maven())
+	assert.Error(v.Validate())
+
+	v.preRelease = "rc.1"
+	assert.Equal("rc.1", v.PreRelease())
+	assert.Equal("1.2.3-rc.1", v.String())
+}
+
+func TestNoMetaAndCore(t *testing.T) {
+	assert := assert.New(t)
+	for _, v := range []Version{
+		{Major: 1, Minor: 2, Patch: 3},
+		{Prefix: "v", Major: 1, Minor: 2, Patch: 3, Commits: 3, Meta: "fcf2c8fa"},
+		{Major: 1, Minor: 2, Patch: 3, preRelease: "rc.1", Meta: "special"},
+	} {
+		s, err := v.Format(NoMetaFormat)
+		assert.NoError(err)
+		assert.Equal(s, v.NoMeta())
+		s, err = v.Format(NoPreFormat)
+		assert.NoError(err)
+		assert.Equal(s, v.Core())
+	}
+	v := Version{Prefix: "v", Major: 1, Minor: 2, Patch: 3, Commits: 3, Meta: "fcf2c8fa"}
+	assert.Equal("v1.2.4-dev.3", v.NoMeta())
+	assert.Equal("v1.2.4", v.Core())
+}
+
+func TestAppendHash(t *testing.T) {
+	assert := assert.New(t)
+	for _, test := range []struct {
+		ref        RepoHead
+		s          string
+		appendHash string
+	}{
+		{
+			RepoHead{LastTag: "v1.2.3+build.7", CommitsSinceTag: 3, Hash: "fcf2c8fa8b6e"},
+			"v1.2.4-dev.3+build.7",
+			"v1.2.4-dev.3+build.7.fcf2c8fa",
+		},
+		{
+			RepoHead{LastTag: "v1.2.3+build.7", Hash: "fcf2c8fa8b6e"},
+			"v1.2.3+build.7",
+			"v1.2.3+build.7",
+		},
+		{
+			RepoHead{LastTag: "v1.2.3", CommitsSinceTag: 3, Hash: "fcf2c8fa8b6e"},
+			"v1.2.4-dev.3+fcf2c8fa",
+			"v1.2.4-dev.3+fcf2c8fa",
+		},
+	} {
+		v, err := NewFromHead(&test.ref)
+		assert.NoError(err)
+		assert.Equal(test.s, v.String())
+		v, err = NewFromHead(&test.ref, WithAppendHash())
+		assert.NoError(err)
+		assert.Equal(test.appendHash, v.String())
+	}
+}
+
+func TestIsZero(t *testing.T) {
+	assert := assert.New(t)
+	assert.True(Version{}.IsZero())
+	v, err := Parse("0.0.0")
+	assert.NoError(err)
+	assert.False(v.IsZero())
+	assert.Equal(0, v.Compare(Version{}))
+	assert.Equal(Version{}.String(), v.String())
+
+	for _, s := range []string{"0.0.0+build.1", "v0.0.0", "0.0.0-rc.1", "0.0.1"} {
+		v, err := Parse(s)
+		assert.NoError(err)
+		assert.False(v.IsZero(), s)
+	}
+	v, err = NewFromHead(&RepoHead{LastTag: "0.0.0"})
+	assert.NoError(err)
+	assert.False(v.IsZero())
+	assert.False(Version{Commits: 1}.IsZero())
 }